@@ -10,13 +10,16 @@ import (
 	"runtime"
 	"syscall"
 	"time"
+	_ "time/tzdata" // IANA zones for recurring and local-time schedules on minimal images
 
 	"github.com/ckshitij/notify-srv/internal/config"
 	"github.com/ckshitij/notify-srv/internal/kafka"
 	"github.com/ckshitij/notify-srv/internal/logger"
 	"github.com/ckshitij/notify-srv/internal/pkg/notification"
 	notfystore "github.com/ckshitij/notify-srv/internal/pkg/notification/store"
+	suppressstore "github.com/ckshitij/notify-srv/internal/pkg/suppression/store"
 	tmplstore "github.com/ckshitij/notify-srv/internal/pkg/template/store"
+	userstore "github.com/ckshitij/notify-srv/internal/pkg/user/store"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/ckshitij/notify-srv/internal/mysql"
@@ -24,23 +27,38 @@ import (
 	"github.com/ckshitij/notify-srv/internal/pkg/senders/email"
 	"github.com/ckshitij/notify-srv/internal/pkg/senders/inapp"
 	"github.com/ckshitij/notify-srv/internal/pkg/senders/slack"
+	"github.com/ckshitij/notify-srv/internal/pkg/senders/sms"
+	"github.com/ckshitij/notify-srv/internal/pkg/senders/webhook"
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
 	"github.com/ckshitij/notify-srv/internal/pkg/template"
+	"github.com/ckshitij/notify-srv/internal/pkg/unsubscribe"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
 	notifyredis "github.com/ckshitij/notify-srv/internal/redis"
 	"github.com/ckshitij/notify-srv/internal/server"
 	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
 func processModules(ctx context.Context, database *mysql.DB, rdb *redis.Client, cfg *config.Config, log logger.Logger) map[string]http.Handler {
+	emailSender, err := email.New(&cfg.SMTP)
+	if err != nil {
+		log.Fatal(ctx, "failed to create email sender", logger.Error(err))
+	}
+	go func() {
+		<-ctx.Done()
+		emailSender.Close()
+	}()
+
+	smsProvider, err := sms.NewProvider(&cfg.SMS, log)
+	if err != nil {
+		log.Fatal(ctx, "failed to create sms provider", logger.Error(err))
+	}
+
 	senders := map[shared.Channel]notification.Sender{
-		shared.ChannelEmail: email.New(
-			cfg.SMTP.Host,
-			cfg.SMTP.Port,
-			cfg.SMTP.From,
-			cfg.SMTP.User,
-			cfg.SMTP.Pass,
-		),
-		shared.ChannelSlack: slack.New(cfg.Slack.WebhookURL),
-		shared.ChannelInApp: inapp.New(database.Conn()),
+		shared.ChannelEmail:   emailSender,
+		shared.ChannelSlack:   slack.New(&cfg.Slack),
+		shared.ChannelInApp:   inapp.New(database.Conn()),
+		shared.ChannelWebhook: webhook.New(&cfg.Webhook),
+		shared.ChannelSMS:     sms.New(smsProvider, cfg.SMS.MaxSegments),
 	}
 
 	producer, err := kafka.NewProducer(cfg.Kafka.Brokers)
@@ -48,6 +66,11 @@ func processModules(ctx context.Context, database *mysql.DB, rdb *redis.Client,
 		log.Fatal(ctx, "failed to create kafka producer", logger.Error(err))
 	}
 
+	replayer, err := kafka.NewReplayer(cfg.Kafka.Brokers, producer)
+	if err != nil {
+		log.Fatal(ctx, "failed to create kafka dead-letter replayer", logger.Error(err))
+	}
+
 	workers := runtime.NumCPU() * 2
 	groupID := "notification-consumer"
 
@@ -57,12 +80,20 @@ func processModules(ctx context.Context, database *mysql.DB, rdb *redis.Client,
 
 	templateRepo.CacheReloadSystemTemplates(context.Background())
 
+	userRepo := userstore.NewUserRepository(database, rdb, log)
+	userService := user.NewUserService(userRepo)
+
+	suppressionRepo := suppressstore.NewSuppressionRepository(database, log)
+	suppressionService := suppression.NewSuppressionService(suppressionRepo)
+
 	notificationRepo := notfystore.NewNotificationRepository(database, log)
-	notificationSrv := notification.NewNotificationService(notificationRepo, renderer, senders, templateRepo, log, producer, &cfg.Kafka)
+	notificationSrv := notification.NewNotificationService(notificationRepo, renderer, senders, templateRepo, log, replayer, &cfg.Kafka, &cfg.Retry, &cfg.Idempotency, &cfg.Batch, &cfg.Unsubscribe, &cfg.Attachments, userService, suppressionService)
 	scheduler := notification.NewSchedular(notificationRepo, log, 5*time.Second, 50, workers, producer, &cfg.Kafka)
+	outboxRelay := notification.NewOutboxRelay(notificationRepo, log, time.Second, 500, producer)
 
-	for _, topic := range cfg.Kafka.Topics {
-		consumer, err := kafka.NewConsumer(cfg.Kafka.Brokers, groupID, topic, notificationSrv, log, workers)
+	for channel, topic := range cfg.Kafka.Topics {
+		dlqTopic := cfg.Kafka.DeadLetterTopics[channel]
+		consumer, err := kafka.NewConsumer(cfg.Kafka.Brokers, groupID, topic, dlqTopic, producer, notificationSrv, log, workers)
 		if err != nil {
 			log.Fatal(ctx, "failed to create kafka consumer", logger.Error(err))
 		}
@@ -70,11 +101,19 @@ func processModules(ctx context.Context, database *mysql.DB, rdb *redis.Client,
 	}
 
 	go scheduler.Run(ctx)
+	go outboxRelay.Run(ctx)
 
 	return map[string]http.Handler{
-		"/v1/admin/templates": template.NewAdminTemplateRoutes(templateService),
-		"/v1/templates":       template.NewTemplateRoutes(templateService),
-		"/v1/notifications":   notification.NewNotificationRoutes(notificationSrv),
+		"/v1/admin/templates":     template.NewAdminTemplateRoutes(templateService),
+		"/v1/admin/notifications": notification.NewAdminNotificationRoutes(notificationSrv),
+		"/v1/admin/suppressions":  suppression.NewAdminSuppressionRoutes(suppressionService),
+		"/v1/templates":           template.NewTemplateRoutes(templateService),
+		"/v1/notifications":       notification.NewNotificationRoutes(notificationSrv, userService),
+		"/v1/recurring":           notification.NewRecurringRoutes(notificationSrv, userService),
+		"/v1/quiet-hours":         notification.NewQuietHoursRoutes(notificationSrv),
+		"/v1/users":               user.NewUserRoutes(userService),
+		"/v1/messages":            notification.NewMessageRoutes(notificationSrv, userService),
+		"/v1/unsubscribe":         unsubscribe.NewUnsubscribeRoutes(cfg.Unsubscribe.Secret, userService, suppressionService),
 	}
 }
 
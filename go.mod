@@ -4,13 +4,16 @@ go 1.24.2
 
 require (
 	github.com/IBM/sarama v1.46.3
+	github.com/emersion/go-msgauth v0.7.0
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/google/uuid v1.6.0
 	github.com/redis/go-redis/v9 v9.17.2
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/viper v1.21.0
 	github.com/stretchr/testify v1.11.1
 	github.com/swaggo/http-swagger v1.3.4
 	go.uber.org/zap v1.27.1
+	golang.org/x/net v0.47.0
 )
 
 require (
@@ -48,7 +51,6 @@ require (
 	github.com/swaggo/swag v1.8.1 // indirect
 	go.yaml.in/yaml/v2 v2.4.2 // indirect
 	golang.org/x/crypto v0.44.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/tools v0.38.0 // indirect
 	google.golang.org/protobuf v1.36.8 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
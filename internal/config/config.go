@@ -2,19 +2,30 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	App        AppConfig        `mapstructure:"app"`
-	MySQL      MySQLConfig      `mapstructure:"mysql"`
-	Redis      RedisConfig      `mapstructure:"redis"`
-	Kafka      KafkaConfig      `mapstructure:"kafka"`
-	Prometheus PrometheusConfig `mapstructure:"prometheus"`
-	SMTP       SMTPConfig       `mapstructure:"smtp"`
-	Slack      SlackConfig      `mapstructure:"slack"`
+	App         AppConfig         `mapstructure:"app"`
+	MySQL       MySQLConfig       `mapstructure:"mysql"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	Kafka       KafkaConfig       `mapstructure:"kafka"`
+	Prometheus  PrometheusConfig  `mapstructure:"prometheus"`
+	SMTP        SMTPConfig        `mapstructure:"smtp"`
+	Slack       SlackConfig       `mapstructure:"slack"`
+	Retry       RetryConfig       `mapstructure:"retry"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Batch       BatchConfig       `mapstructure:"batch"`
+	Unsubscribe UnsubscribeConfig `mapstructure:"unsubscribe"`
+	Attachments AttachmentConfig  `mapstructure:"attachments"`
+	Webhook     WebhookConfig     `mapstructure:"webhook"`
+	SMS         SMSConfig         `mapstructure:"sms"`
 }
 
 type AppConfig struct {
@@ -26,6 +37,11 @@ type AppConfig struct {
 
 type SlackConfig struct {
 	WebhookURL string `mapstructure:"webhook_url"`
+	// BotToken switches to the Web API, which delivers to each recipient's
+	// user or channel ID instead of the one webhook.
+	BotToken string        `mapstructure:"bot_token"`
+	APIURL   string        `mapstructure:"api_url"`
+	Timeout  time.Duration `mapstructure:"timeout"`
 }
 
 type SMTPConfig struct {
@@ -34,6 +50,28 @@ type SMTPConfig struct {
 	User string `mapstructure:"user"`
 	Pass string `mapstructure:"pass"`
 	From string `mapstructure:"from"`
+	// AllowedFrom lists the addresses a request may send from besides From.
+	AllowedFrom []string `mapstructure:"allowed_from"`
+
+	// TLS is none, starttls (required) or tls (implicit, usually port 465).
+	// Empty upgrades with STARTTLS when the server offers it.
+	TLS    string `mapstructure:"tls"`
+	CAFile string `mapstructure:"ca_file"`
+
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	SendTimeout time.Duration `mapstructure:"send_timeout"`
+	PoolSize    int           `mapstructure:"pool_size"`
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+	DKIM DKIMConfig `mapstructure:"dkim"`
+}
+
+// DKIMConfig enables signing of outgoing email when Domain is set. The key
+// is a PEM encoded RSA or Ed25519 private key; the algorithm follows from it.
+type DKIMConfig struct {
+	Domain         string `mapstructure:"domain"`
+	Selector       string `mapstructure:"selector"`
+	PrivateKeyPath string `mapstructure:"private_key_path"`
 }
 
 type MySQLConfig struct {
@@ -67,8 +105,163 @@ type RedisConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers []string          `mapstructure:"brokers"`
-	Topics  map[string]string `mapstructure:"topics"`
+	Brokers          []string          `mapstructure:"brokers"`
+	Topics           map[string]string `mapstructure:"topics"`
+	DeadLetterTopics map[string]string `mapstructure:"dead_letter_topics"`
+}
+
+type RetryPolicy struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+	Jitter      float64       `mapstructure:"jitter"` // fraction of the delay, 0..1
+}
+
+type RetryConfig struct {
+	Default  RetryPolicy            `mapstructure:"default"`
+	Channels map[string]RetryPolicy `mapstructure:"channels"`
+}
+
+// defaultMaxAttempts applies when neither the channel nor the default policy
+// sets max_attempts, so a missing setting does not make every failure final.
+const defaultMaxAttempts = 5
+
+// ForChannel returns the default policy with any non-zero per-channel overrides
+// applied; a zero field inherits the default.
+func (r RetryConfig) ForChannel(channel string) RetryPolicy {
+	policy := r.Default
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultMaxAttempts
+	}
+	override, ok := r.Channels[channel]
+	if !ok {
+		return policy
+	}
+
+	if override.MaxAttempts > 0 {
+		policy.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelay > 0 {
+		policy.BaseDelay = override.BaseDelay
+	}
+	if override.MaxDelay > 0 {
+		policy.MaxDelay = override.MaxDelay
+	}
+	if override.Jitter > 0 {
+		policy.Jitter = override.Jitter
+	}
+	return policy
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"` // replay window, 24h when unset
+	// Lease bounds how long a key stays reserved for a request that has not
+	// finished, e.g. because the instance crashed; the key is free after it.
+	// The request is cut off at the lease, which is at least the server's
+	// 10s write timeout.
+	Lease time.Duration `mapstructure:"lease"`
+}
+
+type BatchConfig struct {
+	MaxRecipients   int `mapstructure:"max_recipients"`
+	InsertChunkSize int `mapstructure:"insert_chunk_size"` // rows per multi-row INSERT
+}
+
+// UnsubscribeConfig enables one-click unsubscribe links for email. It is
+// disabled while Secret is empty.
+type UnsubscribeConfig struct {
+	Secret     string   `mapstructure:"secret"`     // HMAC key signing the tokens
+	BaseURL    string   `mapstructure:"base_url"`   // public URL of /v1/unsubscribe
+	Categories []string `mapstructure:"categories"` // template categories with links, empty means all
+}
+
+// Enabled reports whether emails of the template category get an unsubscribe link.
+func (u UnsubscribeConfig) Enabled(category string) bool {
+	if u.Secret == "" {
+		return false
+	}
+	if len(u.Categories) == 0 {
+		return true
+	}
+	return slices.Contains(u.Categories, category)
+}
+
+type AttachmentConfig struct {
+	MaxCount     int   `mapstructure:"max_count"`      // per notification
+	MaxSize      int64 `mapstructure:"max_size"`       // bytes per attachment
+	MaxTotalSize int64 `mapstructure:"max_total_size"` // bytes per notification
+	// URL attachments must be at or below one of these, e.g. the blob store's
+	// "https://files.example.com/"; none disables URL attachments
+	AllowedURLPrefixes []string      `mapstructure:"allowed_url_prefixes"`
+	FetchTimeout       time.Duration `mapstructure:"fetch_timeout"`
+}
+
+// AllowsURL reports whether an attachment may be fetched from url.
+func (a AttachmentConfig) AllowsURL(url string) bool {
+	return allowsURL(url, a.AllowedURLPrefixes)
+}
+
+// WebhookConfig configures the webhook channel. Requests are signed when
+// Secret is set.
+type WebhookConfig struct {
+	Secret  string        `mapstructure:"secret"` // HMAC-SHA256 key of the signature header
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Recipient URLs must be at or below one of these; none disables the channel
+	AllowedURLPrefixes []string `mapstructure:"allowed_url_prefixes"`
+}
+
+// AllowsURL reports whether notifications may be posted to url.
+func (w WebhookConfig) AllowsURL(url string) bool {
+	return allowsURL(url, w.AllowedURLPrefixes)
+}
+
+// allowsURL reports whether raw is an http(s) URL below one of prefixes. The
+// scheme and host, port included, must match a prefix exactly and its path
+// only matches whole segments, so "https://files.example.com/public" allows
+// neither "https://files.example.com.evil.net/public" nor
+// "https://files.example.com/public-private". URLs carrying userinfo are
+// refused, and dot segments are resolved before matching.
+func allowsURL(raw string, prefixes []string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil || u.Host == "" {
+		return false
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return false
+	}
+	p := path.Clean("/" + u.Path)
+
+	for _, prefix := range prefixes {
+		allowed, err := url.Parse(prefix)
+		if err != nil || allowed.Host == "" {
+			continue
+		}
+		if !strings.EqualFold(u.Scheme, allowed.Scheme) || !strings.EqualFold(u.Host, allowed.Host) {
+			continue
+		}
+		base := strings.TrimSuffix(path.Clean("/"+allowed.Path), "/")
+		if base == "" || p == base || strings.HasPrefix(p, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SMSConfig selects the SMS provider, which must be set: log, which only logs
+// that a message was sent and is meant for development, or twilio for Twilio
+// or an API compatible with it.
+type SMSConfig struct {
+	Provider    string        `mapstructure:"provider"`
+	From        string        `mapstructure:"from"`         // sender number or alphanumeric sender ID
+	MaxSegments int           `mapstructure:"max_segments"` // longer bodies fail, 0 means no limit
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Twilio      TwilioConfig  `mapstructure:"twilio"`
+}
+
+type TwilioConfig struct {
+	BaseURL    string `mapstructure:"base_url"`
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
 }
 
 type PrometheusConfig struct {
@@ -93,5 +286,24 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.checkSecrets(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// checkSecrets refuses to start with a secret left at a documented
+// placeholder, which would let anyone sign unsubscribe tokens or webhooks.
+func (c *Config) checkSecrets() error {
+	secrets := map[string]string{
+		"unsubscribe.secret": c.Unsubscribe.Secret,
+		"webhook.secret":     c.Webhook.Secret,
+	}
+	for key, secret := range secrets {
+		if strings.HasPrefix(secret, "change-me") {
+			return fmt.Errorf("%s is still a placeholder, set a random value or leave it empty", key)
+		}
+	}
+	return nil
+}
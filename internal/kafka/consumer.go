@@ -3,6 +3,8 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/ckshitij/notify-srv/internal/logger"
@@ -12,12 +14,24 @@ type NotificationService interface {
 	Process(ctx context.Context, notificationID int64) error
 }
 
+// Backoff between processing attempts of a message that failed for a reason
+// other than the notification itself, e.g. the database being unavailable.
+const (
+	processRetryBase = time.Second
+	processRetryMax  = 30 * time.Second
+)
+
 type Consumer struct {
-	group   sarama.ConsumerGroup
-	topic   string
-	service NotificationService
-	log     logger.Logger
-	workers int
+	group    sarama.ConsumerGroup
+	topic    string
+	dlqTopic string
+	producer *Producer
+	service  NotificationService
+	log      logger.Logger
+	workers  int
+
+	retryBase time.Duration
+	retryMax  time.Duration
 
 	// internal
 	workerPool chan struct{}
@@ -27,6 +41,8 @@ func NewConsumer(
 	brokers []string,
 	groupID string,
 	topic string,
+	dlqTopic string,
+	producer *Producer,
 	service NotificationService,
 	log logger.Logger,
 	workers int,
@@ -46,11 +62,15 @@ func NewConsumer(
 	}
 
 	return &Consumer{
-		group:   group,
-		topic:   topic,
-		service: service,
-		log:     log,
-		workers: workers,
+		group:     group,
+		topic:     topic,
+		dlqTopic:  dlqTopic,
+		producer:  producer,
+		service:   service,
+		log:       log,
+		workers:   workers,
+		retryBase: processRetryBase,
+		retryMax:  processRetryMax,
 	}, nil
 }
 
@@ -111,25 +131,64 @@ func (c *Consumer) ConsumeClaim(
 		go func(m *sarama.ConsumerMessage) {
 			defer func() { <-c.workerPool }()
 
-			var notificationID int64
-			if err := json.Unmarshal(m.Value, &notificationID); err != nil {
-				c.log.Error(session.Context(), "failed to unmarshal message", logger.Error(err))
-				return
-			}
-
-			if err := c.service.Process(session.Context(), notificationID); err != nil {
-				c.log.Error(
-					session.Context(),
-					"failed to process notification",
-					logger.Error(err),
-					logger.Int64("notification_id", notificationID),
-				)
-				return
+			reason, err := c.handle(session.Context(), m)
+			switch {
+			case err == nil:
+				session.MarkMessage(m, "")
+			case reason != "":
+				c.deadLetterAndMark(session, m, reason, err)
+			default:
+				// the session ended; the message is delivered again after the rebalance
 			}
-
-			session.MarkMessage(m, "")
 		}(msg)
 	}
 
 	return nil
 }
+
+// handle processes one message. It returns a dead-letter reason with the
+// error for undecodable messages and permanent failures. Other failures are
+// retried in place with backoff, as dead-lettering them would move live
+// traffic aside during a short outage; an error without a reason means the
+// session ended before the message was processed.
+func (c *Consumer) handle(ctx context.Context, m *sarama.ConsumerMessage) (string, error) {
+	var notificationID int64
+	if err := json.Unmarshal(m.Value, &notificationID); err != nil {
+		c.log.Error(ctx, "failed to unmarshal message", logger.Error(err))
+		return ReasonUndecodable, err
+	}
+
+	delay := c.retryBase
+	for {
+		err := c.service.Process(ctx, notificationID)
+		if err == nil {
+			return "", nil
+		}
+		if permanent(err) {
+			c.log.Error(ctx, "failed to process notification",
+				logger.Error(err),
+				logger.Int64("notification_id", notificationID),
+			)
+			return ReasonProcessingFailed, err
+		}
+
+		c.log.Warn(ctx, "failed to process notification, retrying",
+			logger.Error(err),
+			logger.Int64("notification_id", notificationID),
+			logger.Any("retry_in", delay),
+		)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, c.retryMax)
+	}
+}
+
+// permanent reports whether err is marked as a failure that processing the
+// message again cannot fix.
+func permanent(err error) bool {
+	var p interface{ Permanent() bool }
+	return errors.As(err, &p) && p.Permanent()
+}
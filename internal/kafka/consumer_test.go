@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...logger.Field) {}
+func (nopLogger) Info(context.Context, string, ...logger.Field)  {}
+func (nopLogger) Warn(context.Context, string, ...logger.Field)  {}
+func (nopLogger) Error(context.Context, string, ...logger.Field) {}
+func (nopLogger) Fatal(context.Context, string, ...logger.Field) {}
+
+type permanentErr struct{ error }
+
+func (permanentErr) Permanent() bool { return true }
+
+// fakeService fails with the queued errors, then succeeds.
+type fakeService struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeService) Process(context.Context, int64) error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func newTestConsumer(service NotificationService) *Consumer {
+	return &Consumer{service: service, log: nopLogger{}, retryBase: time.Millisecond, retryMax: time.Millisecond}
+}
+
+func TestHandleRetriesTransientFailures(t *testing.T) {
+	service := &fakeService{errs: []error{errors.New("connection refused"), errors.New("connection refused")}}
+
+	reason, err := newTestConsumer(service).handle(context.Background(), &sarama.ConsumerMessage{Value: []byte("42")})
+	require.NoError(t, err)
+	require.Empty(t, reason)
+	require.Equal(t, 3, service.calls)
+}
+
+func TestHandleDeadLettersPermanentFailures(t *testing.T) {
+	cause := permanentErr{errors.New("template not found")}
+	service := &fakeService{errs: []error{errors.Join(errors.New("process"), cause)}}
+
+	reason, err := newTestConsumer(service).handle(context.Background(), &sarama.ConsumerMessage{Value: []byte("42")})
+	require.ErrorIs(t, err, cause)
+	require.Equal(t, ReasonProcessingFailed, reason)
+	require.Equal(t, 1, service.calls)
+
+	reason, _ = newTestConsumer(service).handle(context.Background(), &sarama.ConsumerMessage{Value: []byte("not-an-id")})
+	require.Equal(t, ReasonUndecodable, reason)
+}
+
+func TestHandleStopsWithSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service := &fakeService{errs: []error{errors.New("connection refused")}}
+
+	reason, err := newTestConsumer(service).handle(ctx, &sarama.ConsumerMessage{Value: []byte("42")})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, reason)
+}
@@ -0,0 +1,164 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ckshitij/notify-srv/internal/logger"
+)
+
+// Headers attached to every message published on a dead-letter topic.
+const (
+	HeaderDLQReason            = "x-dlq-reason"
+	HeaderDLQError             = "x-dlq-error"
+	HeaderDLQOriginalTopic     = "x-dlq-original-topic"
+	HeaderDLQOriginalPartition = "x-dlq-original-partition"
+	HeaderDLQOriginalOffset    = "x-dlq-original-offset"
+	HeaderDLQFailedAt          = "x-dlq-failed-at"
+	HeaderReplayedFrom         = "x-replayed-from"
+)
+
+const (
+	ReasonUndecodable      = "undecodable"
+	ReasonProcessingFailed = "processing_failed"
+)
+
+// deadLetterAndMark publishes the message to the dead-letter topic and commits its
+// offset. If the dead-letter publish fails the offset is left uncommitted so the
+// message is redelivered after the next rebalance instead of being lost.
+func (c *Consumer) deadLetterAndMark(session sarama.ConsumerGroupSession, m *sarama.ConsumerMessage, reason string, cause error) {
+	ctx := session.Context()
+
+	if c.dlqTopic == "" {
+		c.log.Warn(ctx, "no dead-letter topic configured, dropping message",
+			logger.String("topic", m.Topic),
+			logger.Int64("offset", m.Offset),
+			logger.String("reason", reason),
+		)
+		session.MarkMessage(m, "")
+		return
+	}
+
+	headers := map[string]string{
+		HeaderDLQReason:            reason,
+		HeaderDLQError:             cause.Error(),
+		HeaderDLQOriginalTopic:     m.Topic,
+		HeaderDLQOriginalPartition: strconv.FormatInt(int64(m.Partition), 10),
+		HeaderDLQOriginalOffset:    strconv.FormatInt(m.Offset, 10),
+		HeaderDLQFailedAt:          time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if _, _, err := c.producer.SendMessageWithHeaders(c.dlqTopic, string(m.Key), m.Value, headers); err != nil {
+		c.log.Error(ctx, "failed to publish message to dead-letter topic",
+			logger.String("dlq_topic", c.dlqTopic),
+			logger.Int64("offset", m.Offset),
+			logger.Error(err),
+		)
+		return
+	}
+
+	session.MarkMessage(m, "")
+}
+
+type Replayer struct {
+	client   sarama.Client
+	producer *Producer
+}
+
+func NewReplayer(brokers []string, producer *Producer) (*Replayer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_8_0_0
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{client: client, producer: producer}, nil
+}
+
+// DeadLetter is a message read back from a dead-letter topic.
+type DeadLetter struct {
+	Key   string
+	Value []byte
+	// Source is where the message was read, as topic/partition/offset.
+	Source string
+}
+
+// Read returns the messages of one dead-letter partition in the inclusive
+// offset range [from, to]. The range is clamped to the offsets currently
+// retained by the broker.
+func (r *Replayer) Read(ctx context.Context, dlqTopic string, partition int32, from, to int64) ([]DeadLetter, error) {
+	oldest, err := r.client.GetOffset(dlqTopic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return nil, err
+	}
+	newest, err := r.client.GetOffset(dlqTopic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	from = max(from, oldest)
+	to = min(to, newest-1)
+	if from > to {
+		return nil, nil
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(r.client)
+	if err != nil {
+		return nil, err
+	}
+	defer consumer.Close()
+
+	pc, err := consumer.ConsumePartition(dlqTopic, partition, from)
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+
+	var letters []DeadLetter
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case err := <-pc.Errors():
+			return nil, err
+
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return letters, nil
+			}
+
+			letters = append(letters, DeadLetter{
+				Key:    string(msg.Key),
+				Value:  msg.Value,
+				Source: fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset),
+			})
+
+			if msg.Offset >= to {
+				return letters, nil
+			}
+		}
+	}
+}
+
+// Publish republishes letters onto the target topic, recording where each came
+// from, and returns how many were sent.
+func (r *Replayer) Publish(targetTopic string, letters []DeadLetter) (int, error) {
+	for i, l := range letters {
+		headers := map[string]string{HeaderReplayedFrom: l.Source}
+		if _, _, err := r.producer.SendMessageWithHeaders(targetTopic, l.Key, l.Value, headers); err != nil {
+			return i, err
+		}
+	}
+	return len(letters), nil
+}
+
+func (r *Replayer) Close() error {
+	return r.client.Close()
+}
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"errors"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -42,6 +43,10 @@ func NewProducer(brokers []string) (*Producer, error) {
 }
 
 func (p *Producer) SendMessage(topic string, key string, message []byte) (int32, int64, error) {
+	return p.SendMessageWithHeaders(topic, key, message, nil)
+}
+
+func (p *Producer) SendMessageWithHeaders(topic string, key string, message []byte, headers map[string]string) (int32, int64, error) {
 
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
@@ -49,9 +54,59 @@ func (p *Producer) SendMessage(topic string, key string, message []byte) (int32,
 		Value: sarama.ByteEncoder(message),
 	}
 
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{
+			Key:   []byte(k),
+			Value: []byte(v),
+		})
+	}
+
 	return p.producer.SendMessage(msg)
 }
 
+// Message is a single record for SendMessages.
+type Message struct {
+	Topic string
+	Key   string
+	Value []byte
+}
+
+// SendMessages publishes messages in one batch and returns the errors of the
+// ones that failed, keyed by their index in messages.
+func (p *Producer) SendMessages(messages []Message) map[int]error {
+	msgs := make([]*sarama.ProducerMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = &sarama.ProducerMessage{
+			Topic:    m.Topic,
+			Key:      sarama.StringEncoder(m.Key),
+			Value:    sarama.ByteEncoder(m.Value),
+			Metadata: i,
+		}
+	}
+
+	err := p.producer.SendMessages(msgs)
+	if err == nil {
+		return nil
+	}
+
+	failed := map[int]error{}
+	var perr sarama.ProducerErrors
+	if !errors.As(err, &perr) {
+		// Not attributable to single messages, treat the whole batch as failed
+		for i := range messages {
+			failed[i] = err
+		}
+		return failed
+	}
+
+	for _, e := range perr {
+		if i, ok := e.Msg.Metadata.(int); ok {
+			failed[i] = e.Err
+		}
+	}
+	return failed
+}
+
 func (p *Producer) Close() error {
 	return p.producer.Close()
 }
@@ -60,6 +60,21 @@ func (d *DB) QueryRowContext(ctx context.Context, queryName, query string, args
 	return row
 }
 
+// WithTx runs fn inside a transaction, committing when fn returns nil and rolling back otherwise.
+func (d *DB) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&Tx{tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (d *DB) Conn() *sql.DB {
 	return d.conn
 }
@@ -74,3 +89,23 @@ func (d *DB) Health(ctx context.Context) error {
 
 	return d.conn.PingContext(ctx)
 }
+
+type Tx struct {
+	tx *sql.Tx
+}
+
+func (t *Tx) ExecContext(ctx context.Context, queryName, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	duration := time.Since(start).Milliseconds()
+	metrics.SQLQueryDuration.WithLabelValues(queryName).Observe(float64(duration))
+	return res, err
+}
+
+func (t *Tx) QueryContext(ctx context.Context, queryName, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	duration := time.Since(start).Milliseconds()
+	metrics.SQLQueryDuration.WithLabelValues(queryName).Observe(float64(duration))
+	return rows, err
+}
@@ -0,0 +1,232 @@
+// Package blockkit validates Slack Block Kit layouts and derives the plain
+// text Slack shows where blocks cannot be rendered, such as notifications.
+package blockkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Limits from the Block Kit reference.
+const (
+	maxBlocks          = 50
+	maxBlockIDLength   = 255
+	maxTextLength      = 3000
+	maxHeaderLength    = 150
+	maxFields          = 10
+	maxContextElements = 10
+	maxActionElements  = 25
+	maxButtonText      = 75
+	maxURLLength       = 3000
+)
+
+// Block is the subset of a layout block that is validated. Unknown fields
+// are kept as is in the raw JSON sent to Slack.
+type Block struct {
+	Type     string    `json:"type"`
+	BlockID  string    `json:"block_id,omitempty"`
+	Text     *Text     `json:"text,omitempty"`
+	Fields   []Text    `json:"fields,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+	ImageURL string    `json:"image_url,omitempty"`
+	AltText  string    `json:"alt_text,omitempty"`
+}
+
+// Text is a text composition object.
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Element is an element of a context or actions block.
+type Element struct {
+	Type     string `json:"type"`
+	Text     any    `json:"text,omitempty"`
+	ActionID string `json:"action_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	AltText  string `json:"alt_text,omitempty"`
+}
+
+// Parse validates a layout, given as an array of blocks or as an object with
+// a blocks array, and returns the blocks array as JSON along with the parsed
+// blocks.
+func Parse(raw []byte) (json.RawMessage, []Block, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var wrapper struct {
+			Blocks json.RawMessage `json:"blocks"`
+		}
+		if err := json.Unmarshal(raw, &wrapper); err != nil {
+			return nil, nil, fmt.Errorf("invalid block kit json: %w", err)
+		}
+		raw = wrapper.Blocks
+	}
+
+	var blocks []Block
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, nil, fmt.Errorf("invalid block kit json: %w", err)
+	}
+	if len(blocks) == 0 || len(blocks) > maxBlocks {
+		return nil, nil, fmt.Errorf("block kit layout must have 1 to %d blocks", maxBlocks)
+	}
+
+	for i, b := range blocks {
+		if err := b.validate(); err != nil {
+			return nil, nil, fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, nil, err
+	}
+	return compact.Bytes(), blocks, nil
+}
+
+func (b Block) validate() error {
+	if len(b.BlockID) > maxBlockIDLength {
+		return fmt.Errorf("block_id longer than %d characters", maxBlockIDLength)
+	}
+
+	switch b.Type {
+	case "section":
+		if b.Text == nil && len(b.Fields) == 0 {
+			return errors.New("section needs text or fields")
+		}
+		if b.Text != nil {
+			if err := b.Text.validate(maxTextLength, false); err != nil {
+				return err
+			}
+		}
+		if len(b.Fields) > maxFields {
+			return fmt.Errorf("section has more than %d fields", maxFields)
+		}
+		for _, f := range b.Fields {
+			if err := f.validate(2000, false); err != nil {
+				return err
+			}
+		}
+	case "header":
+		if b.Text == nil {
+			return errors.New("header needs text")
+		}
+		return b.Text.validate(maxHeaderLength, true)
+	case "divider":
+	case "image":
+		if b.ImageURL == "" || b.AltText == "" {
+			return errors.New("image needs image_url and alt_text")
+		}
+		if len(b.ImageURL) > maxURLLength {
+			return fmt.Errorf("image_url longer than %d characters", maxURLLength)
+		}
+	case "context":
+		if len(b.Elements) == 0 || len(b.Elements) > maxContextElements {
+			return fmt.Errorf("context needs 1 to %d elements", maxContextElements)
+		}
+		for _, e := range b.Elements {
+			if err := e.validateContext(); err != nil {
+				return err
+			}
+		}
+	case "actions":
+		if len(b.Elements) == 0 || len(b.Elements) > maxActionElements {
+			return fmt.Errorf("actions needs 1 to %d elements", maxActionElements)
+		}
+		for _, e := range b.Elements {
+			if err := e.validateAction(); err != nil {
+				return err
+			}
+		}
+	case "rich_text", "input", "video", "file", "markdown":
+		// passed through, Slack validates their richer structure
+	case "":
+		return errors.New("missing block type")
+	default:
+		return fmt.Errorf("unknown block type %q", b.Type)
+	}
+	return nil
+}
+
+func (t Text) validate(maxLength int, plainOnly bool) error {
+	switch {
+	case t.Type == "mrkdwn" && plainOnly:
+		return errors.New("text must be plain_text")
+	case t.Type != "plain_text" && t.Type != "mrkdwn":
+		return fmt.Errorf("text type must be plain_text or mrkdwn, got %q", t.Type)
+	case strings.TrimSpace(t.Text) == "":
+		return errors.New("text is empty")
+	case len([]rune(t.Text)) > maxLength:
+		return fmt.Errorf("text longer than %d characters", maxLength)
+	}
+	return nil
+}
+
+func (e Element) validateContext() error {
+	switch e.Type {
+	case "plain_text", "mrkdwn":
+		text, _ := e.Text.(string)
+		return Text{Type: e.Type, Text: text}.validate(maxTextLength, false)
+	case "image":
+		if e.ImageURL == "" || e.AltText == "" {
+			return errors.New("context image needs image_url and alt_text")
+		}
+		return nil
+	default:
+		return fmt.Errorf("context element must be text or image, got %q", e.Type)
+	}
+}
+
+func (e Element) validateAction() error {
+	if e.Type == "" {
+		return errors.New("missing element type")
+	}
+	if len(e.URL) > maxURLLength {
+		return fmt.Errorf("url longer than %d characters", maxURLLength)
+	}
+	if e.Type != "button" {
+		return nil
+	}
+
+	text, ok := e.buttonText()
+	if !ok {
+		return errors.New("button needs plain_text text")
+	}
+	return text.validate(maxButtonText, true)
+}
+
+// buttonText decodes the text object of a button.
+func (e Element) buttonText() (Text, bool) {
+	m, ok := e.Text.(map[string]any)
+	if !ok {
+		return Text{}, false
+	}
+	typ, _ := m["type"].(string)
+	text, _ := m["text"].(string)
+	return Text{Type: typ, Text: text}, true
+}
+
+// FallbackText joins the text of header, section and context blocks, one
+// line each, for the notification text Slack requires alongside blocks.
+func FallbackText(blocks []Block) string {
+	var lines []string
+	for _, b := range blocks {
+		if b.Text != nil {
+			lines = append(lines, b.Text.Text)
+		}
+		for _, f := range b.Fields {
+			lines = append(lines, f.Text)
+		}
+		if b.Type == "context" {
+			for _, e := range b.Elements {
+				if text, ok := e.Text.(string); ok {
+					lines = append(lines, text)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,55 @@
+package blockkit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const layout = `[
+	{"type": "header", "text": {"type": "plain_text", "text": "Deploy finished"}},
+	{"type": "section", "text": {"type": "mrkdwn", "text": "*api* is live"},
+	 "fields": [{"type": "mrkdwn", "text": "*Env*\nprod"}]},
+	{"type": "divider"},
+	{"type": "context", "elements": [{"type": "mrkdwn", "text": "by ci"}]},
+	{"type": "actions", "elements": [
+		{"type": "button", "text": {"type": "plain_text", "text": "Open"}, "url": "https://example.com"}
+	]}
+]`
+
+func TestParse(t *testing.T) {
+	raw, blocks, err := Parse([]byte(layout))
+	require.NoError(t, err)
+	require.Len(t, blocks, 5)
+	require.NotContains(t, string(raw), "\n\t")
+
+	// an object with a blocks array is accepted too
+	_, wrapped, err := Parse([]byte(`{"blocks": ` + layout + `}`))
+	require.NoError(t, err)
+	require.Equal(t, blocks, wrapped)
+
+	require.Equal(t, "Deploy finished\n*api* is live\n*Env*\nprod\nby ci", FallbackText(blocks))
+}
+
+func TestParseRejects(t *testing.T) {
+	cases := map[string]string{
+		"not json":            `[{"type": "section"`,
+		"empty":               `[]`,
+		"not an array":        `{"type": "divider"}`,
+		"unknown type":        `[{"type": "banner"}]`,
+		"section no text":     `[{"type": "section"}]`,
+		"mrkdwn header":       `[{"type": "header", "text": {"type": "mrkdwn", "text": "x"}}]`,
+		"long header":         `[{"type": "header", "text": {"type": "plain_text", "text": "` + strings.Repeat("x", 151) + `"}}]`,
+		"button without text": `[{"type": "actions", "elements": [{"type": "button", "action_id": "a"}]}]`,
+		"image without alt":   `[{"type": "image", "image_url": "https://example.com/a.png"}]`,
+		"too many blocks":     `[` + strings.Repeat(`{"type": "divider"},`, 50) + `{"type": "divider"}]`,
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Parse([]byte(raw))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/dlq/{channel}/replay", h.ReplayDeadLetters)
+
+	return r
+}
+
+func NewAdminNotificationRoutes(service Service) http.Handler {
+	return NewHandler(service, nil).AdminRoutes()
+}
@@ -0,0 +1,178 @@
+package notification
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+const defaultAttachmentContentType = "application/octet-stream"
+
+// mapAttachments decodes the attachments of a send request. Size limits and
+// URL prefixes are checked by the service, which owns the configuration.
+func mapAttachments(channel shared.Channel, reqs []AttachmentRequest) ([]Attachment, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if channel != shared.ChannelEmail {
+		return nil, shared.ErrAttachmentsEmailOnly
+	}
+
+	out := make([]Attachment, 0, len(reqs))
+	for _, req := range reqs {
+		if req.Filename == "" || strings.ContainsAny(req.Filename, "\r\n") {
+			return nil, errors.New("attachment filename is required")
+		}
+		if (req.Content == "") == (req.URL == "") {
+			return nil, errors.New("attachment " + req.Filename + ": provide either content or url")
+		}
+		if req.Inline && req.ContentID == "" {
+			return nil, errors.New("attachment " + req.Filename + ": inline attachments need a content_id")
+		}
+
+		a := Attachment{
+			Filename:    req.Filename,
+			ContentType: req.ContentType,
+			URL:         req.URL,
+			Inline:      req.Inline,
+			ContentID:   strings.Trim(req.ContentID, "<>"),
+		}
+		if a.ContentType == "" {
+			a.ContentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+		}
+		if _, _, err := mime.ParseMediaType(a.ContentType); err != nil {
+			a.ContentType = defaultAttachmentContentType
+		}
+
+		if req.Content != "" {
+			content, err := base64.StdEncoding.DecodeString(req.Content)
+			if err != nil {
+				return nil, errors.New("attachment " + req.Filename + ": content is not valid base64")
+			}
+			a.Content = content
+			a.Size = int64(len(content))
+		}
+
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// validateForSender checks the attachments and message reference and lets
+// the channel's sender reject the notification if it implements SendValidator.
+func (s *serviceImpl) validateForSender(ctx context.Context, n *Notification) error {
+	if err := s.validateAttachments(n); err != nil {
+		return err
+	}
+	if err := s.validateReference(ctx, n); err != nil {
+		return err
+	}
+	if v, ok := s.senders[n.Channel].(SendValidator); ok {
+		return v.Validate(*n)
+	}
+	return nil
+}
+
+// validateAttachments enforces the configured count and size limits and only
+// lets URL attachments point below an allowed prefix, so the service cannot be
+// used to fetch arbitrary internal URLs.
+func (s *serviceImpl) validateAttachments(n *Notification) error {
+	if len(n.Attachments) == 0 {
+		return nil
+	}
+	cfg := s.attachmentCfg
+	if cfg == nil || len(n.Attachments) > cfg.MaxCount {
+		return shared.ErrTooManyAttachments
+	}
+
+	var total int64
+	for _, a := range n.Attachments {
+		if a.URL != "" && !cfg.AllowsURL(a.URL) {
+			return shared.ErrAttachmentURLNotAllowed
+		}
+		if a.Size > cfg.MaxSize {
+			return shared.ErrAttachmentTooLarge
+		}
+		total += a.Size
+	}
+	if total > cfg.MaxTotalSize {
+		return shared.ErrAttachmentTooLarge
+	}
+	return nil
+}
+
+// loadAttachments reads the stored attachments of an email and fetches the
+// content of URL ones. Content that can never be delivered, because it is
+// gone or over the size limit, fails the notification for good.
+func (s *serviceImpl) loadAttachments(ctx context.Context, n *Notification) error {
+	if n.Channel != shared.ChannelEmail {
+		return nil
+	}
+
+	attachments, err := s.repo.ListAttachments(ctx, n.ID)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for i := range attachments {
+		a := &attachments[i]
+		if a.URL != "" {
+			if a.Content, err = s.fetchAttachment(ctx, a.URL); err != nil {
+				return err
+			}
+			a.Size = int64(len(a.Content))
+		}
+		total += a.Size
+	}
+	if s.attachmentCfg != nil && total > s.attachmentCfg.MaxTotalSize {
+		return permanent(shared.ErrAttachmentTooLarge)
+	}
+
+	n.Attachments = attachments
+	return nil
+}
+
+func (s *serviceImpl) fetchAttachment(ctx context.Context, url string) ([]byte, error) {
+	cfg := s.attachmentCfg
+	if cfg == nil || !cfg.AllowsURL(url) {
+		return nil, permanent(shared.ErrAttachmentURLNotAllowed)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, permanent(err)
+	}
+
+	client := &http.Client{Timeout: cfg.FetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch attachment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("fetch attachment: %s returned %d", url, resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, permanent(err)
+		}
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, cfg.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch attachment: %w", err)
+	}
+	if int64(len(content)) > cfg.MaxSize {
+		return nil, permanent(shared.ErrAttachmentTooLarge)
+	}
+	return content, nil
+}
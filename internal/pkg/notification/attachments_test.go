@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapAttachments(t *testing.T) {
+	out, err := mapAttachments(shared.ChannelEmail, []AttachmentRequest{
+		{Filename: "invoice.pdf", Content: "JVBERi0xLjc="},
+		{Filename: "logo.png", URL: "https://files.example.com/logo.png", Inline: true, ContentID: "<logo>"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "application/pdf", out[0].ContentType)
+	require.Equal(t, []byte("%PDF-1.7"), out[0].Content)
+	require.EqualValues(t, 8, out[0].Size)
+	require.Equal(t, "logo", out[1].ContentID)
+
+	_, err = mapAttachments(shared.ChannelSlack, []AttachmentRequest{{Filename: "a.txt", Content: "YQ=="}})
+	require.ErrorIs(t, err, shared.ErrAttachmentsEmailOnly)
+
+	_, err = mapAttachments(shared.ChannelEmail, []AttachmentRequest{{Filename: "a.txt", Content: "YQ==", URL: "https://files.example.com/a.txt"}})
+	require.Error(t, err)
+
+	_, err = mapAttachments(shared.ChannelEmail, []AttachmentRequest{{Filename: "a.png", Content: "YQ==", Inline: true}})
+	require.Error(t, err)
+}
+
+func TestValidateAttachments(t *testing.T) {
+	s := &serviceImpl{attachmentCfg: &config.AttachmentConfig{
+		MaxCount:           2,
+		MaxSize:            10,
+		MaxTotalSize:       15,
+		AllowedURLPrefixes: []string{"https://files.example.com/"},
+	}}
+
+	ok := &Notification{Attachments: []Attachment{{Size: 10}, {URL: "https://files.example.com/a.pdf"}}}
+	require.NoError(t, s.validateAttachments(ok))
+
+	tooLarge := &Notification{Attachments: []Attachment{{Size: 11}}}
+	require.ErrorIs(t, s.validateAttachments(tooLarge), shared.ErrAttachmentTooLarge)
+
+	totalTooLarge := &Notification{Attachments: []Attachment{{Size: 8}, {Size: 8}}}
+	require.ErrorIs(t, s.validateAttachments(totalTooLarge), shared.ErrAttachmentTooLarge)
+
+	tooMany := &Notification{Attachments: []Attachment{{}, {}, {}}}
+	require.ErrorIs(t, s.validateAttachments(tooMany), shared.ErrTooManyAttachments)
+
+	foreign := &Notification{Attachments: []Attachment{{URL: "https://files.example.com.evil.net/a.pdf"}}}
+	require.ErrorIs(t, s.validateAttachments(foreign), shared.ErrAttachmentURLNotAllowed)
+}
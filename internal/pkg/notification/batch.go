@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// CheckBatchSize rejects a batch with more recipients than configured, before
+// any of them is mapped.
+func (s *serviceImpl) CheckBatchSize(total int) error {
+	if s.batchCfg.MaxRecipients > 0 && total > s.batchCfg.MaxRecipients {
+		return shared.ErrBatchTooLarge
+	}
+	return nil
+}
+
+// SendBatch persists every valid notification of a bulk send, together with
+// the batch and their outbox entries, in a single transaction. Notifications
+// failing the checks of a single send are left out and returned by their
+// index in ns.
+func (s *serviceImpl) SendBatch(ctx context.Context, b *NotificationBatch, ns []*Notification) (map[int]error, error) {
+	if err := s.CheckBatchSize(b.Total); err != nil {
+		return nil, err
+	}
+
+	topic, ok := s.kafkaCfg.Topics[string(b.Channel)]
+	if !ok {
+		return nil, fmt.Errorf("kafka topic not found for channel %s", b.Channel)
+	}
+
+	rejected := make(map[int]error)
+	valid := make([]*Notification, 0, len(ns))
+	for i, n := range ns {
+		if err := s.prepare(ctx, n); err != nil {
+			// a failing lookup fails the batch, it says nothing about the item
+			if shared.ErrorHttpMapper(err) >= http.StatusInternalServerError {
+				return nil, err
+			}
+			rejected[i] = err
+			continue
+		}
+		n.Status = StatusPending
+		if n.Priority == "" {
+			n.Priority = PriorityNormal
+		}
+		valid = append(valid, n)
+	}
+	if len(valid) == 0 {
+		return rejected, shared.ErrEmptyBatch
+	}
+	b.Accepted = len(valid)
+
+	if err := s.repo.CreateBatch(ctx, b, valid, topic, s.batchCfg.InsertChunkSize); err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "notification batch accepted",
+		logger.Int64("batch_id", b.ID),
+		logger.Int("total", b.Total),
+		logger.Int("accepted", b.Accepted),
+	)
+	return rejected, nil
+}
+
+func (s *serviceImpl) GetBatch(ctx context.Context, id int64) (*NotificationBatch, error) {
+	b, err := s.repo.GetBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountBatchStatuses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Counts = counts
+	b.Completed = true
+	for status, count := range counts {
+		if count > 0 && !isTerminal(status) {
+			b.Completed = false
+		}
+	}
+	return b, nil
+}
+
+func isTerminal(status NotificationStatus) bool {
+	switch status {
+	case StatusSent, StatusFailed, StatusCancelled, StatusSuppressed:
+		return true
+	}
+	return false
+}
+
+// mergeKeyValues overlays the recipient specific template values on the batch defaults.
+func mergeKeyValues(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	maps.Copy(merged, base)
+	maps.Copy(merged, override)
+	return merged
+}
@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxBatchBodyBytes bounds the request body of a bulk send.
+const maxBatchBodyBytes = 32 << 20
+
+// SendBatch accepts one template for many recipients. Recipients that fail
+// validation are reported per item and do not fail the rest of the batch.
+func (h *Handler) SendBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+
+	var req BatchSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// before mapping, which may look every recipient up in the user directory
+	if err := h.service.CheckBatchSize(len(req.Recipients)); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	batch := &NotificationBatch{
+		Channel:    req.Channel,
+		TemplateID: req.TemplateID,
+		Total:      len(req.Recipients),
+	}
+
+	items := make([]BatchItemResult, len(req.Recipients))
+	accepted := make([]*Notification, 0, len(req.Recipients))
+	acceptedIdx := make([]int, 0, len(req.Recipients))
+
+	for i, rcpt := range req.Recipients {
+		n, err := mapRequestToNotification(r.Context(), SendNowRequest{
+			Channel:          req.Channel,
+			TemplateID:       req.TemplateID,
+			Recipient:        rcpt.Recipient,
+			TemplateKeyValue: mergeKeyValues(req.TemplateKeyValue, rcpt.TemplateKeyValue),
+			Priority:         req.Priority,
+		}, h.users)
+		if err != nil {
+			items[i] = BatchItemResult{Index: i, Status: "rejected", Error: err.Error()}
+			continue
+		}
+		accepted = append(accepted, n)
+		acceptedIdx = append(acceptedIdx, i)
+	}
+
+	if len(accepted) == 0 {
+		http.Error(w, shared.ErrEmptyBatch.Error(), shared.ErrorHttpMapper(shared.ErrEmptyBatch))
+		return
+	}
+
+	rejected, err := h.service.SendBatch(r.Context(), batch, accepted)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	for j, n := range accepted {
+		i := acceptedIdx[j]
+		if err, ok := rejected[j]; ok {
+			items[i] = BatchItemResult{Index: i, Status: "rejected", Error: err.Error()}
+			continue
+		}
+		items[i] = BatchItemResult{Index: i, ID: n.ID, Status: string(n.Status)}
+	}
+
+	shared.WriteJSON(w, http.StatusAccepted, BatchSendResponse{
+		BatchID:  batch.ID,
+		Total:    batch.Total,
+		Accepted: batch.Accepted,
+		Rejected: batch.Total - batch.Accepted,
+		Items:    items,
+	})
+}
+
+func (h *Handler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	batchID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || batchID <= 0 {
+		http.Error(w, "invalid batch ID ", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := h.service.GetBatch(r.Context(), batchID)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, batch)
+}
@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeKeyValues(t *testing.T) {
+	base := map[string]any{"Product": "Notify", "UserName": "there"}
+
+	merged := mergeKeyValues(base, map[string]any{"UserName": "Kshitij"})
+	require.Equal(t, map[string]any{"Product": "Notify", "UserName": "Kshitij"}, merged)
+
+	// batch level values are shared between recipients and must not change
+	require.Equal(t, "there", base["UserName"])
+
+	require.Equal(t, base, mergeKeyValues(base, nil))
+}
+
+func (r *fakeRepo) CreateBatch(_ context.Context, b *NotificationBatch, ns []*Notification, _ string, _ int) error {
+	b.ID = 1
+	for _, n := range ns {
+		n.ID = int64(len(r.notifications) + 1)
+		r.notifications[n.ID] = n
+	}
+	return nil
+}
+
+// allowSender rejects in-app notifications to any user but the allowed one.
+type allowSender struct {
+	fakeSender
+	allowed string
+}
+
+func (s *allowSender) Validate(n Notification) error {
+	if n.Recipient.InAppUser == nil || *n.Recipient.InAppUser != s.allowed {
+		return shared.ErrSenderNotAllowed
+	}
+	return nil
+}
+
+func newBatchTestService(repo *fakeRepo) *serviceImpl {
+	svc := newTestService(repo)
+	svc.senders[shared.ChannelInApp] = &allowSender{allowed: "42"}
+	svc.kafkaCfg = &config.KafkaConfig{Topics: map[string]string{string(shared.ChannelInApp): "in_app"}}
+	svc.batchCfg = &config.BatchConfig{MaxRecipients: 2}
+	return svc
+}
+
+func TestSendBatchRejectsInvalidItems(t *testing.T) {
+	repo := newFakeRepo()
+	svc := newBatchTestService(repo)
+
+	blocked := inAppNotification(0)
+	blocked.Recipient.InAppUser = new(string)
+	b := &NotificationBatch{Channel: shared.ChannelInApp, TemplateID: 7, Total: 2}
+
+	rejected, err := svc.SendBatch(context.Background(), b, []*Notification{blocked, inAppNotification(0)})
+	require.NoError(t, err)
+	require.Len(t, rejected, 1)
+	require.ErrorIs(t, rejected[0], shared.ErrSenderNotAllowed)
+	require.Equal(t, 1, b.Accepted)
+	require.Len(t, repo.notifications, 1)
+
+	_, err = svc.SendBatch(context.Background(), &NotificationBatch{Channel: shared.ChannelInApp, Total: 1}, []*Notification{blocked})
+	require.ErrorIs(t, err, shared.ErrEmptyBatch)
+}
+
+func TestSendBatchHandler(t *testing.T) {
+	repo := newFakeRepo()
+	srv := httptest.NewServer(NewNotificationRoutes(newBatchTestService(repo), nil))
+	defer srv.Close()
+
+	post := func(body string) *http.Response {
+		resp, err := http.Post(srv.URL+"/batch", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post(`{"channel":"in_app","template_id":7,"template_key_value":{"Name":"Ada"},
+		"recipients":[{"recipient":{"user":"7"}},{"recipient":{"user":"42"}}]}`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var out BatchSendResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, 1, out.Accepted)
+	require.Equal(t, 1, out.Rejected)
+	require.Equal(t, "rejected", out.Items[0].Status)
+	require.Equal(t, shared.ErrSenderNotAllowed.Error(), out.Items[0].Error)
+	require.Equal(t, string(StatusPending), out.Items[1].Status)
+
+	resp = post(`{"channel":"in_app","template_id":7,"recipients":[{},{},{}]}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(`{"channel":"in_app","template_id":7,"template_key_value":{"Pad":"` + strings.Repeat("x", maxBatchBodyBytes) + `"}}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/kafka"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReplayer serves letters and records what is republished.
+type fakeReplayer struct {
+	letters   []kafka.DeadLetter
+	published []kafka.DeadLetter
+}
+
+func (f *fakeReplayer) Read(context.Context, string, int32, int64, int64) ([]kafka.DeadLetter, error) {
+	return f.letters, nil
+}
+
+func (f *fakeReplayer) Publish(_ string, letters []kafka.DeadLetter) (int, error) {
+	f.published = append(f.published, letters...)
+	return len(letters), nil
+}
+
+func (r *fakeRepo) ResetFailed(_ context.Context, ids []int64) (int, error) {
+	reset := 0
+	for _, id := range ids {
+		if r.statuses[id] == StatusFailed {
+			r.statuses[id] = StatusPending
+			r.notifications[id].Attempts = 0
+			r.notifications[id].StatusReason = ""
+			reset++
+		}
+	}
+	return reset, nil
+}
+
+func TestReplayDeadLettersRedeliversPermanentFailure(t *testing.T) {
+	n := inAppNotification(1)
+	n.Status = StatusFailed
+	n.Attempts = 3
+	repo := newFakeRepo(n)
+	sender := &fakeSender{}
+	replayer := &fakeReplayer{letters: []kafka.DeadLetter{
+		{Key: "1", Value: []byte("1"), Source: "in_app.dlq/0/7"},
+		{Key: "x", Value: []byte("not json"), Source: "in_app.dlq/0/8"},
+	}}
+
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+	s.replayer = replayer
+	s.kafkaCfg = &config.KafkaConfig{
+		Topics:           map[string]string{"in_app": "in_app"},
+		DeadLetterTopics: map[string]string{"in_app": "in_app.dlq"},
+	}
+
+	// without the reset the failed row is not acquired and nothing is sent
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Empty(t, sender.sent)
+
+	resp, err := s.ReplayDeadLetters(context.Background(), shared.ChannelInApp, DeadLetterReplayRequest{ToOffset: 10})
+	require.NoError(t, err)
+	require.Equal(t, &DeadLetterReplayResponse{Replayed: 1, Reset: 1, Skipped: []string{"in_app.dlq/0/8"}}, resp)
+	require.Len(t, replayer.published, 1)
+
+	// the consumer processes the republished message
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Len(t, sender.sent, 1)
+	require.Equal(t, StatusSent, repo.statuses[1])
+}
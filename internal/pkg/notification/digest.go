@@ -0,0 +1,245 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// digestTime is when, in the user's own timezone, digest notifications are
+// delivered each day.
+const digestTime = "09:00"
+
+// nextDigest returns the first digest time in timezone strictly after now, in UTC.
+func nextDigest(timezone string, now time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, shared.ErrInvalidTimezone
+	}
+
+	at, err := time.Parse(clockLayout, digestTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, at.Hour(), at.Minute(), 0, 0, loc)
+	}
+	return next.UTC(), nil
+}
+
+// digestKey identifies the digest of a user on a channel at one digest time.
+func digestKey(userID string, channel shared.Channel, at time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", channel, at.UTC().Format(time.RFC3339), userID)
+}
+
+// holdForDigest hands a notification back to the scheduler until the user's
+// next digest. Notifications with attachments or a Slack thread reference
+// cannot be merged into one message and are sent on their own. Like the
+// other preferences it is best effort, a failure is logged and the send goes
+// ahead.
+func (s *serviceImpl) holdForDigest(ctx context.Context, n *Notification, userID string) bool {
+	if n.Recipient.ThreadOf != nil || n.Recipient.UpdateOf != nil {
+		return false
+	}
+	if n.Channel == shared.ChannelEmail {
+		attachments, err := s.repo.ListAttachments(ctx, n.ID)
+		if err != nil {
+			s.log.Warn(ctx, "failed to load attachments for digest, sending anyway",
+				logger.Int64("notificationID", n.ID),
+				logger.Error(err),
+			)
+			return false
+		}
+		if len(attachments) > 0 {
+			return false
+		}
+	}
+
+	until, err := nextDigest(s.userTimezone(ctx, n), time.Now())
+	if err != nil {
+		s.log.Warn(ctx, "invalid user timezone for digest, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+
+	held, err := s.repo.HoldForDigest(ctx, n.ID, until, reasonDigest, digestKey(userID, n.Channel, until))
+	if err != nil {
+		s.log.Warn(ctx, "failed to hold notification for digest, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+	if !held {
+		s.leftSending(ctx, n)
+		return true
+	}
+
+	s.log.Info(ctx, "notification held for user digest",
+		logger.Int64("notificationID", n.ID),
+		logger.Any("scheduled_at", until),
+	)
+	return true
+}
+
+// digestEntry is a notification of a digest with its rendered content.
+type digestEntry struct {
+	n       *Notification
+	attempt NotificationAttempt
+	content renderer.RenderedTemplate
+	result  SendResult
+	err     error
+}
+
+// deliverDigest delivers n together with the other notifications of its
+// digest as one message. Each notification keeps its own attempt and status:
+// one that fails to render fails alone, while a failed send is handled for
+// every notification in the message.
+func (s *serviceImpl) deliverDigest(ctx context.Context, n *Notification) error {
+	entries := []*digestEntry{{n: n}}
+	for _, m := range s.claimDigest(ctx, n) {
+		entries = append(entries, &digestEntry{n: m})
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	var rendered []*digestEntry
+	for _, e := range entries {
+		e.attempt = NotificationAttempt{
+			NotificationID: e.n.ID,
+			Attempt:        e.n.Attempts,
+			Sender:         string(e.n.Channel),
+			StartedAt:      time.Now(),
+		}
+		if e.content, e.err = s.render(deliverCtx, e.n); e.err == nil {
+			rendered = append(rendered, e)
+		}
+	}
+
+	if len(rendered) > 0 {
+		parts := make([]renderer.RenderedTemplate, len(rendered))
+		for i, e := range rendered {
+			parts[i] = e.content
+		}
+		// every notification of a digest goes to the same user on the same channel
+		result, err := s.send(deliverCtx, rendered[0].n, combineDigest(parts))
+		for _, e := range rendered {
+			e.result, e.err = result, err
+		}
+	}
+	cancel()
+
+	var leaderErr error
+	for i, e := range entries {
+		s.recordAttempt(ctx, e.attempt, e.result, e.err)
+
+		var err error
+		if e.err != nil {
+			err = s.handleFailure(ctx, e.n, e.err)
+		} else {
+			err = s.markSent(ctx, e.n, e.result)
+		}
+		if i == 0 {
+			leaderErr = err
+		} else if err != nil {
+			s.log.Warn(ctx, "failed to finish digest notification",
+				logger.Int64("notificationID", e.n.ID),
+				logger.Error(err),
+			)
+		}
+	}
+
+	s.log.Info(ctx, "delivered user digest",
+		logger.Int64("notificationID", n.ID),
+		logger.Int("notifications", len(entries)),
+	)
+	return leaderErr
+}
+
+// claimDigest takes over the other notifications of n's digest that still
+// need sending. A claimed notification that the suppression list or a changed
+// preference ends, or whose suppression lookup fails and is retried, is left
+// out. Claimed notifications that cannot be loaded
+// stay sending and are recovered by the scheduler.
+func (s *serviceImpl) claimDigest(ctx context.Context, n *Notification) []*Notification {
+	ids, err := s.repo.ClaimDigest(ctx, n.DigestKey, n.ID)
+	if err != nil {
+		s.log.Warn(ctx, "failed to claim digest, sending alone",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return nil
+	}
+
+	var members []*Notification
+	for _, id := range ids {
+		m, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			s.log.Warn(ctx, "failed to load digest notification",
+				logger.Int64("notificationID", id),
+				logger.Error(err),
+			)
+			continue
+		}
+
+		suppressed, err := s.checkSuppressionList(ctx, m)
+		if err != nil {
+			if err := s.handleFailure(ctx, m, err); err != nil {
+				s.log.Warn(ctx, "failed to check digest notification against the suppression list",
+					logger.Int64("notificationID", id),
+					logger.Error(err),
+				)
+			}
+			continue
+		}
+		if suppressed || s.applyPreferences(ctx, m) {
+			continue
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+// combineDigest joins the rendered notifications of a digest into one
+// message. Slack blocks are not merged, the digest is sent as text.
+func combineDigest(parts []renderer.RenderedTemplate) renderer.RenderedTemplate {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	bodies := make([]string, len(parts))
+	htmlBodies := make([]string, len(parts))
+	hasHTML := false
+	for i, p := range parts {
+		bodies[i] = p.Body
+		htmlBodies[i] = p.HTMLBody
+		if p.HTMLBody == "" {
+			htmlBodies[i] = "<p>" + html.EscapeString(p.Body) + "</p>"
+		} else {
+			hasHTML = true
+		}
+	}
+
+	digest := renderer.RenderedTemplate{
+		Subject: fmt.Sprintf("Your digest: %d notifications", len(parts)),
+		Body:    strings.Join(bodies, "\n\n"),
+	}
+	if hasHTML {
+		digest.HTMLBody = strings.Join(htmlBodies, "\n<hr>\n")
+	}
+	if parts[0].SMS != nil {
+		info := renderer.CountSMS(digest.Body)
+		digest.SMS = &info
+	}
+	return digest
+}
@@ -0,0 +1,140 @@
+package notification
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *fakeRepo) ListAttachments(context.Context, int64) ([]Attachment, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) HoldForDigest(_ context.Context, id int64, until time.Time, reason, key string) (bool, error) {
+	if r.statuses[id] != StatusSending {
+		return false, nil
+	}
+	r.statuses[id] = StatusScheduled
+	r.retries[id] = until
+	r.notifications[id].StatusReason = reason
+	r.notifications[id].DigestKey = key
+	return true, nil
+}
+
+func (r *fakeRepo) ClaimDigest(_ context.Context, key string, deliveredBy int64) ([]int64, error) {
+	var ids []int64
+	for id, n := range r.notifications {
+		if n.DigestKey != key || id == deliveredBy {
+			continue
+		}
+		if s := r.statuses[id]; s == StatusScheduled || s == StatusDispatched {
+			r.statuses[id] = StatusSending
+			n.Attempts++
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func TestNextDigest(t *testing.T) {
+	// 07:00 in New York (EDT, UTC-4) is delivered at 09:00 the same day
+	next, err := nextDigest("America/New_York", time.Date(2026, 7, 1, 11, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 7, 1, 13, 0, 0, 0, time.UTC), next)
+
+	// exactly 09:00 rolls over to the next day
+	next, err = nextDigest("UTC", time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC), next)
+
+	_, err = nextDigest("Nowhere/Land", time.Now())
+	require.ErrorIs(t, err, shared.ErrInvalidTimezone)
+}
+
+func TestCombineDigest(t *testing.T) {
+	one := renderer.RenderedTemplate{Subject: "Hello", Body: "Hi Ada"}
+	require.Equal(t, one, combineDigest([]renderer.RenderedTemplate{one}))
+
+	digest := combineDigest([]renderer.RenderedTemplate{
+		one,
+		{Subject: "News", Body: "a < b", HTMLBody: "<b>a &lt; b</b>"},
+	})
+	require.Equal(t, "Your digest: 2 notifications", digest.Subject)
+	require.Equal(t, "Hi Ada\n\na < b", digest.Body)
+	require.Equal(t, "<p>Hi Ada</p>\n<hr>\n<b>a &lt; b</b>", digest.HTMLBody)
+}
+
+func TestDigestDeliversHeldNotificationsTogether(t *testing.T) {
+	userID := "42"
+	var ns []*Notification
+	for id := int64(1); id <= 3; id++ {
+		n := inAppNotification(id)
+		n.Recipient.UserID = &userID
+		ns = append(ns, n)
+	}
+	ns[2].TemplateKeyValue = map[string]any{"Name": "Grace"}
+
+	repo := newFakeRepo(ns...)
+	sender := &fakeSender{}
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+	s.preferences = userDirectory{fakePreferences: fakePreferences(user.PreferenceDigest), timezone: "Asia/Tokyo"}
+
+	// every notification is held for the same digest
+	for _, n := range ns {
+		require.NoError(t, s.Process(context.Background(), n.ID))
+		require.Equal(t, StatusScheduled, repo.statuses[n.ID])
+		require.Equal(t, reasonDigest, repo.notifications[n.ID].StatusReason)
+	}
+	require.Empty(t, sender.sent)
+	key := repo.notifications[1].DigestKey
+	require.NotEmpty(t, key)
+	require.Equal(t, key, repo.notifications[3].DigestKey)
+
+	want, err := nextDigest("Asia/Tokyo", time.Now())
+	require.NoError(t, err)
+	require.Equal(t, want, repo.retries[1])
+
+	// the digest is due and the scheduler dispatches its first notification
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Len(t, sender.sent, 1)
+	require.Equal(t, "Hi Ada\n\nHi Ada\n\nHi Grace", sender.sent[0].Body)
+	for _, n := range ns {
+		require.Equal(t, StatusSent, repo.statuses[n.ID])
+	}
+	require.Len(t, repo.attempts, 3)
+
+	// a late delivery of a claimed notification sends nothing
+	require.NoError(t, s.Process(context.Background(), 2))
+	require.Len(t, sender.sent, 1)
+}
+
+func TestDigestSendFailureRetriesEveryNotification(t *testing.T) {
+	userID := "42"
+	repo := newFakeRepo()
+	for id := int64(1); id <= 2; id++ {
+		n := inAppNotification(id)
+		n.Recipient.UserID = &userID
+		n.Status = StatusScheduled
+		n.DigestKey = "in_app:2026-07-01T09:00:00Z:42"
+		repo.notifications[id] = n
+		repo.statuses[id] = n.Status
+	}
+	sender := &fakeSender{errs: []error{context.DeadlineExceeded}}
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+	s.preferences = userDirectory{fakePreferences: fakePreferences(user.PreferenceDigest)}
+
+	require.NoError(t, s.Process(context.Background(), 1))
+	for id := int64(1); id <= 2; id++ {
+		require.Equal(t, StatusScheduled, repo.statuses[id])
+		require.Contains(t, repo.retries, id)
+	}
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// maxEmailRecipients bounds to, cc and bcc addresses of a single email.
+const maxEmailRecipients = 50
+
+// applyEmailOptions validates the email options of a send request and stores
+// them on the recipient. Whether the From address may be used is up to the
+// email sender, see SendValidator.
+func applyEmailOptions(channel shared.Channel, opts *EmailOptions, r *NotificationRecipient) error {
+	if opts == nil {
+		return nil
+	}
+	if channel != shared.ChannelEmail {
+		return errors.New("email options are only supported for the email channel")
+	}
+
+	if 1+len(opts.CC)+len(opts.BCC) > maxEmailRecipients {
+		return errors.New("too many email recipients")
+	}
+
+	var err error
+	if r.CC, err = parseAddressList("cc", opts.CC); err != nil {
+		return err
+	}
+	if r.BCC, err = parseAddressList("bcc", opts.BCC); err != nil {
+		return err
+	}
+
+	if opts.ReplyTo != "" {
+		addr, err := mail.ParseAddress(opts.ReplyTo)
+		if err != nil {
+			return errors.New("invalid reply_to address")
+		}
+		replyTo := addr.String()
+		r.ReplyTo = &replyTo
+	}
+
+	if opts.From != "" {
+		addr, err := mail.ParseAddress(opts.From)
+		if err != nil {
+			return errors.New("invalid from address")
+		}
+		from := addr.String()
+		r.From = &from
+	}
+
+	for name, value := range opts.Headers {
+		if !validCustomHeader(name) {
+			return errors.New("invalid header " + name + ", only X- headers are allowed")
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("invalid value for header " + name)
+		}
+	}
+	if len(opts.Headers) > 0 {
+		r.Headers = opts.Headers
+	}
+
+	return nil
+}
+
+func parseAddressList(field string, list []string) ([]string, error) {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		addr, err := mail.ParseAddress(v)
+		if err != nil {
+			return nil, errors.New("invalid " + field + " address " + v)
+		}
+		out = append(out, addr.Address)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// validCustomHeader accepts X- header names made of RFC 5322 field name characters.
+func validCustomHeader(name string) bool {
+	if len(name) <= 2 || !strings.EqualFold(name[:2], "x-") {
+		return false
+	}
+	for _, c := range name {
+		if c <= ' ' || c > '~' || c == ':' {
+			return false
+		}
+	}
+	return true
+}
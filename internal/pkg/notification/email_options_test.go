@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEmailOptions(t *testing.T) {
+	var r NotificationRecipient
+	err := applyEmailOptions(shared.ChannelEmail, &EmailOptions{
+		CC:      []string{"Carol <carol@example.com>"},
+		BCC:     []string{"audit@example.com"},
+		ReplyTo: "support@example.com",
+		From:    "Billing <billing@notify.local>",
+		Headers: map[string]string{"X-Campaign": "spring"},
+	}, &r)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"carol@example.com"}, r.CC)
+	require.Equal(t, []string{"audit@example.com"}, r.BCC)
+	require.Equal(t, "<support@example.com>", *r.ReplyTo)
+	require.Equal(t, `"Billing" <billing@notify.local>`, *r.From)
+	require.Equal(t, map[string]string{"X-Campaign": "spring"}, r.Headers)
+}
+
+func TestApplyEmailOptionsRejects(t *testing.T) {
+	cases := map[string]struct {
+		channel shared.Channel
+		opts    EmailOptions
+	}{
+		"other channel":       {shared.ChannelSlack, EmailOptions{ReplyTo: "a@example.com"}},
+		"bad cc":              {shared.ChannelEmail, EmailOptions{CC: []string{"not an address"}}},
+		"bad reply_to":        {shared.ChannelEmail, EmailOptions{ReplyTo: "nope"}},
+		"non X- header":       {shared.ChannelEmail, EmailOptions{Headers: map[string]string{"Sender": "a@example.com"}}},
+		"header injection":    {shared.ChannelEmail, EmailOptions{Headers: map[string]string{"X-Tag": "a\r\nBcc: eve@example.com"}}},
+		"too many recipients": {shared.ChannelEmail, EmailOptions{BCC: make([]string, maxEmailRecipients)}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var r NotificationRecipient
+			require.Error(t, applyEmailOptions(tc.channel, &tc.opts, &r))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// resolveFallbackTemplates fills in the template of every fallback step that
+// did not name one, using the template with the primary template's name on
+// that channel, so a missing template is reported when the request is made.
+func (s *serviceImpl) resolveFallbackTemplates(ctx context.Context, n *Notification) error {
+	var primaryName string
+	for i := range n.Fallback {
+		step := &n.Fallback[i]
+		if step.TemplateID != 0 {
+			continue
+		}
+
+		if primaryName == "" {
+			primary, err := s.templateRepo.GetByID(ctx, n.TemplateID)
+			if err != nil {
+				return err
+			}
+			if primary == nil {
+				return shared.ErrTemplateNotFound
+			}
+			primaryName = primary.Name
+		}
+
+		tpl, err := s.templateForChannel(ctx, primaryName, step.Channel)
+		if err != nil {
+			return err
+		}
+		step.TemplateID = tpl.ID
+	}
+	return nil
+}
+
+// markFailed fails the notification for good and, when it declares a fallback
+// chain, enqueues the next channel in the same transaction.
+func (s *serviceImpl) markFailed(ctx context.Context, n *Notification) error {
+	if len(n.Fallback) == 0 {
+		return s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
+	}
+
+	step := n.Fallback[0]
+	topic, ok := s.kafkaCfg.Topics[string(step.Channel)]
+	if !ok {
+		s.log.Error(ctx, "kafka topic not found for fallback channel", logger.String("channel", string(step.Channel)))
+		return s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
+	}
+
+	parentID := n.ID
+	next := &Notification{
+		ParentID:         &parentID,
+		MessageID:        n.MessageID,
+		Channel:          step.Channel,
+		TemplateID:       step.TemplateID,
+		Recipient:        step.Recipient,
+		TemplateKeyValue: n.TemplateKeyValue,
+		Fallback:         n.Fallback[1:],
+		Status:           StatusPending,
+		Priority:         n.Priority,
+	}
+
+	if err := s.repo.FailWithFallback(ctx, n.ID, next, topic); err != nil {
+		return err
+	}
+	if next.ID == 0 {
+		// Already failed by an earlier delivery of the same message
+		return nil
+	}
+
+	s.log.Info(ctx, "notification failed, falling back to next channel",
+		logger.Int64("notificationID", n.ID),
+		logger.Int64("fallback_notification_id", next.ID),
+		logger.String("channel", string(next.Channel)),
+	)
+	return nil
+}
+
+// fallbackChain returns the chain n belongs to, from the original notification
+// through every fallback created so far.
+func (s *serviceImpl) fallbackChain(ctx context.Context, n *Notification) ([]ChainLink, error) {
+	root := n
+	for root.ParentID != nil {
+		parent, err := s.repo.GetByID(ctx, *root.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("load fallback parent %d: %w", *root.ParentID, err)
+		}
+		root = parent
+	}
+
+	var chain []ChainLink
+	for current := root; current != nil; {
+		chain = append(chain, ChainLink{
+			ID:       current.ID,
+			Channel:  current.Channel,
+			Status:   current.Status,
+			Attempts: current.Attempts,
+		})
+
+		parentID := current.ID
+		children, err := s.repo.List(ctx, NotificationFilter{ParentID: &parentID})
+		if err != nil {
+			return nil, err
+		}
+		current = nil
+		if len(children) > 0 {
+			current = children[0]
+		}
+	}
+	return chain, nil
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapRequestWithFallback(t *testing.T) {
+	n, err := mapRequestToNotification(context.Background(), SendNowRequest{
+		Channel:    shared.ChannelSlack,
+		TemplateID: 1,
+		Recipient:  map[string]string{"user": "U123"},
+		Fallback: []FallbackRequest{
+			{Channel: shared.ChannelEmail, Recipient: map[string]string{"email": "user@example.com"}},
+			{Channel: shared.ChannelInApp, TemplateID: 7, Recipient: map[string]string{"user": "42"}},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, n.Fallback, 2)
+	require.Equal(t, "user@example.com", *n.Fallback[0].Recipient.Email)
+	require.Equal(t, int64(7), n.Fallback[1].TemplateID)
+}
+
+func TestMapRequestRejectsInvalidFallback(t *testing.T) {
+	_, err := mapRequestToNotification(context.Background(), SendNowRequest{
+		Channel:   shared.ChannelSlack,
+		Recipient: map[string]string{"user": "U123"},
+		Fallback:  []FallbackRequest{{Channel: shared.ChannelSlack, Recipient: map[string]string{"user": "U123"}}},
+	}, nil)
+	require.Error(t, err)
+
+	_, err = mapRequestToNotification(context.Background(), SendNowRequest{
+		Channel:   shared.ChannelSlack,
+		Recipient: map[string]string{"user": "U123"},
+		Fallback:  []FallbackRequest{{Channel: shared.ChannelEmail, Recipient: map[string]string{}}},
+	}, nil)
+	require.EqualError(t, err, "fallback email: email recipient required")
+}
@@ -1,21 +1,38 @@
 package notification
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
 	"github.com/ckshitij/notify-srv/internal/shared"
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	ClientIDHeader           = "X-Client-ID"
+)
+
+// UserDirectory resolves recipients given as {"user_id": ...} to their
+// channel addresses.
+type UserDirectory interface {
+	GetByID(ctx context.Context, userID string) (*user.User, error)
+}
+
 type Handler struct {
 	service Service
+	users   UserDirectory
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, users UserDirectory) *Handler {
+	return &Handler{service: service, users: users}
 }
 
 func (h *Handler) SendNow(w http.ResponseWriter, r *http.Request) {
@@ -25,21 +42,18 @@ func (h *Handler) SendNow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	n, err := mapRequestToNotification(req)
+	n, err := mapRequestToNotification(r.Context(), req, h.users)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	id, err := h.service.SendNow(r.Context(), n)
-	if err != nil {
-		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
-		return
-	}
-
-	shared.WriteJSON(w, http.StatusAccepted, NotificationResponse{
-		ID:     id,
-		Status: string(n.Status),
+	h.withIdempotency(w, r, req, func(ctx context.Context) (*NotificationResponse, error) {
+		id, err := h.service.SendNow(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		return &NotificationResponse{ID: id, Status: string(n.Status)}, nil
 	})
 }
 
@@ -50,24 +64,102 @@ func (h *Handler) Schedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	n, err := mapRequestToNotification(req.SendNowRequest)
+	n, err := mapRequestToNotification(r.Context(), req.SendNowRequest, h.users)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	id, err := h.service.Schedule(r.Context(), n, req.ScheduledAt)
+	when, err := resolveScheduledAt(req)
 	if err != nil {
 		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
 		return
 	}
 
-	shared.WriteJSON(w, http.StatusAccepted, NotificationResponse{
-		ID:     id,
-		Status: string(n.Status),
+	h.withIdempotency(w, r, req, func(ctx context.Context) (*NotificationResponse, error) {
+		id, err := h.service.Schedule(ctx, n, when)
+		if err != nil {
+			return nil, err
+		}
+		return &NotificationResponse{ID: id, Status: string(n.Status)}, nil
 	})
 }
 
+// withIdempotency runs send at most once per client and Idempotency-Key, replaying
+// the original response when the same request is retried within the window.
+// send runs for no longer than the key's lease, after which another request
+// may take the key over.
+func (h *Handler) withIdempotency(w http.ResponseWriter, r *http.Request, req any, send func(ctx context.Context) (*NotificationResponse, error)) {
+	key, err := idempotencyKeyFromRequest(r, req)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	if key != nil {
+		replay, err := h.service.BeginIdempotent(r.Context(), key)
+		if err != nil {
+			http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+			return
+		}
+		if replay != nil {
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			shared.WriteJSON(w, http.StatusAccepted, replay)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if key != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, key.ExpiresAt)
+		defer cancel()
+	}
+
+	resp, err := send(ctx)
+	if key != nil {
+		h.service.FinishIdempotent(r.Context(), key, resp, err)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusAccepted, resp)
+}
+
+// idempotencyKeyFromRequest returns nil when the caller did not send an Idempotency-Key.
+// The request hash covers the route and the decoded body so that reusing a key for a
+// different request can be detected.
+func idempotencyKeyFromRequest(r *http.Request, req any) (*IdempotencyKey, error) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) > 255 {
+		return nil, shared.ErrInvalidIdempotencyKey
+	}
+	clientID := r.Header.Get(ClientIDHeader)
+	if len(clientID) > 64 {
+		return nil, shared.ErrInvalidClientID
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.Path))
+	hash.Write(payload)
+
+	return &IdempotencyKey{
+		ClientID:    clientID,
+		Key:         key,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
 func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	notificationIDStr := chi.URLParam(r, "id")
@@ -86,6 +178,70 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	shared.WriteJSON(w, http.StatusOK, notification)
 }
 
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+
+	notificationIDStr := chi.URLParam(r, "id")
+	notificationID, err := strconv.ParseInt(notificationIDStr, 10, 64)
+	if err != nil || notificationID <= 0 {
+		http.Error(w, "invalid notification ID ", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Cancel(r.Context(), notificationID); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, NotificationResponse{
+		ID:     notificationID,
+		Status: string(StatusCancelled),
+	})
+}
+
+func (h *Handler) Reschedule(w http.ResponseWriter, r *http.Request) {
+
+	notificationIDStr := chi.URLParam(r, "id")
+	notificationID, err := strconv.ParseInt(notificationIDStr, 10, 64)
+	if err != nil || notificationID <= 0 {
+		http.Error(w, "invalid notification ID ", http.StatusBadRequest)
+		return
+	}
+
+	var req RescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Reschedule(r.Context(), notificationID, req.ScheduledAt); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, NotificationResponse{
+		ID:     notificationID,
+		Status: string(StatusScheduled),
+	})
+}
+
+func (h *Handler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+
+	notificationIDStr := chi.URLParam(r, "id")
+	notificationID, err := strconv.ParseInt(notificationIDStr, 10, 64)
+	if err != nil || notificationID <= 0 {
+		http.Error(w, "invalid notification ID ", http.StatusBadRequest)
+		return
+	}
+
+	attempts, err := h.service.ListAttempts(r.Context(), notificationID)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, attempts)
+}
+
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	filter := NotificationFilter{}
@@ -100,6 +256,10 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		filter.Status = &st
 	}
 
+	if messageID, err := strconv.ParseInt(r.URL.Query().Get("message_id"), 10, 64); err == nil {
+		filter.MessageID = &messageID
+	}
+
 	notifications, err := h.service.List(r.Context(), filter)
 	if err != nil {
 		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
@@ -120,6 +280,10 @@ func (h *Handler) Process(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.Process(r.Context(), notificationID)
 	if err != nil {
+		var perm permanentError
+		if errors.As(err, &perm) {
+			err = perm.err
+		}
 		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
 		return
 	}
@@ -127,40 +291,170 @@ func (h *Handler) Process(w http.ResponseWriter, r *http.Request) {
 	shared.WriteJSON(w, http.StatusAccepted, nil)
 }
 
-func mapRequestToNotification(req SendNowRequest) (*Notification, error) {
+func (h *Handler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	channel := shared.Channel(chi.URLParam(r, "channel"))
+
+	var req DeadLetterReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.ReplayDeadLetters(r.Context(), channel, req)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, resp)
+}
+
+func mapRequestToNotification(ctx context.Context, req SendNowRequest, users UserDirectory) (*Notification, error) {
 
 	n := &Notification{
 		Channel:          shared.Channel(req.Channel),
 		TemplateID:       req.TemplateID,
 		TemplateKeyValue: req.TemplateKeyValue,
+		Priority:         req.Priority,
+	}
+
+	switch req.Priority {
+	case "", PriorityNormal, PriorityUrgent:
+	default:
+		return nil, shared.ErrInvalidPriority
 	}
 
-	switch req.Channel {
+	recipient, err := mapRecipient(ctx, req.Channel, req.Recipient, users)
+	if err != nil {
+		return nil, err
+	}
+	n.Recipient = recipient
+
+	if n.Attachments, err = mapAttachments(req.Channel, req.Attachments); err != nil {
+		return nil, err
+	}
+
+	if err := applyEmailOptions(req.Channel, req.Email, &n.Recipient); err != nil {
+		return nil, err
+	}
+
+	if err := applySlackOptions(req.Channel, req.Slack, &n.Recipient); err != nil {
+		return nil, err
+	}
+
+	if err := applyWebhookOptions(req.Channel, req.Webhook, &n.Recipient); err != nil {
+		return nil, err
+	}
+
+	seen := map[shared.Channel]bool{req.Channel: true}
+	for _, step := range req.Fallback {
+		if seen[step.Channel] {
+			return nil, errors.New("fallback channels must be distinct from each other and the primary channel")
+		}
+		seen[step.Channel] = true
+
+		recipient, err := mapRecipient(ctx, step.Channel, step.Recipient, users)
+		if err != nil {
+			return nil, errors.New("fallback " + string(step.Channel) + ": " + err.Error())
+		}
+		n.Fallback = append(n.Fallback, FallbackStep{
+			Channel:    step.Channel,
+			TemplateID: step.TemplateID,
+			Recipient:  recipient,
+		})
+	}
+
+	return n, nil
+}
+
+// mapRecipient validates the single channel recipient format. A recipient given
+// as {"user_id": ...} is looked up in the user directory and addressed by the
+// user's address on channel.
+func mapRecipient(ctx context.Context, channel shared.Channel, req map[string]string, users UserDirectory) (NotificationRecipient, error) {
+	var recipient NotificationRecipient
+
+	if userID := req["user_id"]; userID != "" {
+		resolved, err := resolveUserRecipient(ctx, channel, userID, users)
+		if err != nil {
+			return recipient, err
+		}
+		recipient.UserID = &userID
+		req = resolved
+	}
+
+	switch channel {
 	case "email":
-		email := req.Recipient["email"]
+		email := req["email"]
 		if email == "" {
-			return nil, errors.New("email recipient required")
+			return recipient, errors.New("email recipient required")
 		}
-		n.Recipient.Email = &email
+		recipient.Email = &email
 
 	case "slack":
-		if v := req.Recipient["user"]; v != "" {
-			n.Recipient.SlackUser = &v
+		if v := req["user"]; v != "" {
+			recipient.SlackUser = &v
 		}
-		if n.Recipient.SlackUser == nil {
-			return nil, errors.New("slack user required")
+		if recipient.SlackUser == nil {
+			return recipient, errors.New("slack user required")
 		}
 
 	case "in_app":
-		user := req.Recipient["user"]
+		user := req["user"]
 		if user == "" {
-			return nil, errors.New("in_app user required")
+			return recipient, errors.New("in_app user required")
 		}
-		n.Recipient.InAppUser = &user
+		recipient.InAppUser = &user
+
+	case "webhook":
+		url := req["url"]
+		if url == "" {
+			return recipient, errors.New("webhook url required")
+		}
+		recipient.Webhook = &url
+
+	case "sms":
+		phone := req["phone"]
+		if !shared.ValidPhone(phone) {
+			return recipient, errors.New("sms phone required in E.164 format, e.g. +14155552671")
+		}
+		recipient.Phone = &phone
 
 	default:
-		return nil, errors.New("unsupported channel")
+		return recipient, errors.New("unsupported channel")
 	}
 
-	return n, nil
+	return recipient, nil
+}
+
+func resolveUserRecipient(ctx context.Context, channel shared.Channel, userID string, users UserDirectory) (map[string]string, error) {
+	if users == nil {
+		return nil, errors.New("user_id recipients are not supported here")
+	}
+	// users have no webhook address, and preferences, suppressions and quiet
+	// hours do not cover the channel either
+	if channel == shared.ChannelWebhook {
+		return nil, errors.New("user_id recipients are not supported for webhook, give a url")
+	}
+
+	u, err := users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, shared.ErrRecordNotFound) {
+			return nil, errors.New("unknown user_id " + userID)
+		}
+		return nil, err
+	}
+
+	address := u.Address(channel)
+	if address == "" {
+		return nil, errors.New("user " + userID + " has no " + string(channel) + " address")
+	}
+
+	switch channel {
+	case shared.ChannelEmail:
+		return map[string]string{"email": address}, nil
+	case shared.ChannelSMS:
+		return map[string]string{"phone": address}, nil
+	default:
+		return map[string]string{"user": address}, nil
+	}
 }
@@ -0,0 +1,93 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDirectory map[string]*user.User
+
+func (d fakeDirectory) GetByID(_ context.Context, userID string) (*user.User, error) {
+	if u, ok := d[userID]; ok {
+		return u, nil
+	}
+	return nil, shared.ErrRecordNotFound
+}
+
+func TestMapRecipientResolvesUserID(t *testing.T) {
+	email, phone := "ada@example.com", "+14155552671"
+	users := fakeDirectory{"u123": {ID: "u123", Email: &email, Phone: &phone}, "u456": {ID: "u456"}}
+
+	r, err := mapRecipient(context.Background(), shared.ChannelEmail, map[string]string{"user_id": "u123"}, users)
+	require.NoError(t, err)
+	require.Equal(t, email, *r.Email)
+	require.Equal(t, "u123", *r.UserID)
+
+	// in-app falls back to the user ID
+	r, err = mapRecipient(context.Background(), shared.ChannelInApp, map[string]string{"user_id": "u123"}, users)
+	require.NoError(t, err)
+	require.Equal(t, "u123", *r.InAppUser)
+
+	r, err = mapRecipient(context.Background(), shared.ChannelSMS, map[string]string{"user_id": "u123"}, users)
+	require.NoError(t, err)
+	require.Equal(t, phone, *r.Phone)
+
+	_, err = mapRecipient(context.Background(), shared.ChannelSMS, map[string]string{"user_id": "u456"}, users)
+	require.EqualError(t, err, "user u456 has no sms address")
+
+	_, err = mapRecipient(context.Background(), shared.ChannelSlack, map[string]string{"user_id": "u123"}, users)
+	require.EqualError(t, err, "user u123 has no slack address")
+
+	_, err = mapRecipient(context.Background(), shared.ChannelEmail, map[string]string{"user_id": "nobody"}, users)
+	require.EqualError(t, err, "unknown user_id nobody")
+
+	_, err = mapRecipient(context.Background(), shared.ChannelWebhook, map[string]string{"user_id": "u123"}, users)
+	require.EqualError(t, err, "user_id recipients are not supported for webhook, give a url")
+}
+
+func TestMapRecipientValidatesPhone(t *testing.T) {
+	r, err := mapRecipient(context.Background(), shared.ChannelSMS, map[string]string{"phone": "+14155552671"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "+14155552671", *r.Phone)
+
+	for _, phone := range []string{"", "14155552671", "+0123456", "+1 415 555 2671", "+1234567890123456"} {
+		_, err := mapRecipient(context.Background(), shared.ChannelSMS, map[string]string{"phone": phone}, nil)
+		require.Error(t, err, phone)
+	}
+}
+
+func TestListAttemptsHandler(t *testing.T) {
+	repo := newFakeRepo(inAppNotification(1))
+	repo.attempts = []*NotificationAttempt{
+		{ID: 1, NotificationID: 1, Attempt: 1, Sender: "in_app", Error: "provider unavailable"},
+		{ID: 2, NotificationID: 1, Attempt: 2, Sender: "in_app", ProviderResponse: "ok"},
+		{ID: 3, NotificationID: 2, Attempt: 1, Sender: "in_app"},
+	}
+	srv := httptest.NewServer(NewNotificationRoutes(newTestService(repo), nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/1/attempts")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var attempts []NotificationAttempt
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&attempts))
+	require.Len(t, attempts, 2)
+	require.Equal(t, "provider unavailable", attempts[0].Error)
+	require.Equal(t, "ok", attempts[1].ProviderResponse)
+
+	for path, status := range map[string]int{"/99/attempts": http.StatusNotFound, "/abc/attempts": http.StatusBadRequest} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, path)
+	}
+}
@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// Defaults for an unset idempotency.ttl and idempotency.lease. The lease
+// never goes below minIdempotencyLease, the HTTP server's write timeout, as
+// the request holding a key runs for at most its lease.
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	defaultIdempotencyLease = 30 * time.Second
+	minIdempotencyLease     = 10 * time.Second
+)
+
+// BeginIdempotent reserves the key for a new request. It returns the stored
+// response when the key was already used for the same request within the
+// configured window, and a conflict error when it was used for a different one.
+// The reservation only lasts the lease until the request finishes, so a key
+// left behind by a crashed request can be used again soon.
+func (s *serviceImpl) BeginIdempotent(ctx context.Context, key *IdempotencyKey) (*NotificationResponse, error) {
+	lease := s.idempotencyCfg.Lease
+	if lease <= 0 {
+		lease = defaultIdempotencyLease
+	}
+	lease = max(lease, minIdempotencyLease)
+	key.ExpiresAt = time.Now().Add(lease)
+
+	reserved, err := s.repo.ReserveIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	existing, err := s.repo.GetIdempotencyKey(ctx, key.ClientID, key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired key, or an abandoned reservation, is free to be used again
+	if time.Now().After(existing.ExpiresAt) {
+		reclaimed, err := s.repo.ReclaimIdempotencyKey(ctx, key, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if !reclaimed {
+			return nil, shared.ErrIdempotencyKeyInFlight
+		}
+		return nil, nil
+	}
+
+	if existing.RequestHash != key.RequestHash {
+		return nil, shared.ErrIdempotencyKeyReused
+	}
+
+	if existing.Response == nil {
+		return nil, shared.ErrIdempotencyKeyInFlight
+	}
+
+	return existing.Response, nil
+}
+
+// FinishIdempotent stores the response for replays, or releases the key when the
+// request failed so the caller can retry it. A key reclaimed by another request
+// after the lease ran out is left to that request.
+func (s *serviceImpl) FinishIdempotent(ctx context.Context, key *IdempotencyKey, resp *NotificationResponse, cause error) {
+	ttl := s.idempotencyCfg.TTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	var (
+		owned bool
+		err   error
+	)
+	if cause != nil || resp == nil {
+		owned, err = s.repo.DeleteIdempotencyKey(ctx, key)
+	} else {
+		owned, err = s.repo.CompleteIdempotencyKey(ctx, key, *resp, time.Now().Add(ttl))
+	}
+
+	if err == nil && !owned {
+		s.log.Warn(ctx, "idempotency key was reclaimed before the request finished",
+			logger.String("client_id", key.ClientID),
+			logger.String("idempotency_key", key.Key),
+		)
+		return
+	}
+	if err != nil {
+		s.log.Warn(ctx, "failed to finish idempotency key",
+			logger.String("client_id", key.ClientID),
+			logger.String("idempotency_key", key.Key),
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,203 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeys keeps idempotency keys in memory with the store's semantics.
+type fakeKeys struct {
+	Repository
+	mu   sync.Mutex
+	keys map[string]IdempotencyKey
+}
+
+func (f *fakeKeys) ReserveIdempotencyKey(_ context.Context, key *IdempotencyKey) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[key.ClientID+"/"+key.Key]; ok {
+		return false, nil
+	}
+	f.keys[key.ClientID+"/"+key.Key] = *key
+	return true, nil
+}
+
+func (f *fakeKeys) GetIdempotencyKey(_ context.Context, clientID, key string) (*IdempotencyKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[clientID+"/"+key]
+	if !ok {
+		return nil, shared.ErrRecordNotFound
+	}
+	return &k, nil
+}
+
+func (f *fakeKeys) ReclaimIdempotencyKey(_ context.Context, key *IdempotencyKey, now time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[key.ClientID+"/"+key.Key]
+	if !ok || !k.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	f.keys[key.ClientID+"/"+key.Key] = *key
+	return true, nil
+}
+
+func (f *fakeKeys) CompleteIdempotencyKey(_ context.Context, key *IdempotencyKey, resp NotificationResponse, expiresAt time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[key.ClientID+"/"+key.Key]
+	if !ok || k.RequestHash != key.RequestHash || k.Response != nil {
+		return false, nil
+	}
+	k.Response, k.ExpiresAt = &resp, expiresAt
+	f.keys[key.ClientID+"/"+key.Key] = k
+	return true, nil
+}
+
+func (f *fakeKeys) DeleteIdempotencyKey(_ context.Context, key *IdempotencyKey) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[key.ClientID+"/"+key.Key]
+	if !ok || k.RequestHash != key.RequestHash || k.Response != nil {
+		return false, nil
+	}
+	delete(f.keys, key.ClientID+"/"+key.Key)
+	return true, nil
+}
+
+func newIdempotentHandler(keys *fakeKeys) *Handler {
+	s := newTestService(keys)
+	s.idempotencyCfg = &config.IdempotencyConfig{TTL: time.Hour, Lease: time.Minute}
+	return NewHandler(s, nil)
+}
+
+// send posts body through withIdempotency, counting the sends it lets through.
+func send(h *Handler, key, clientID, body string, sends *int) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/notifications", strings.NewReader(body))
+	r.Header.Set(IdempotencyKeyHeader, key)
+	r.Header.Set(ClientIDHeader, clientID)
+	w := httptest.NewRecorder()
+
+	var req map[string]any
+	json.Unmarshal([]byte(body), &req)
+	h.withIdempotency(w, r, req, func(context.Context) (*NotificationResponse, error) {
+		*sends++
+		return &NotificationResponse{ID: int64(*sends), Status: string(StatusPending)}, nil
+	})
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	h := newIdempotentHandler(&fakeKeys{keys: map[string]IdempotencyKey{}})
+	sends := 0
+
+	first := send(h, "k1", "billing", `{"template_id": 1}`, &sends)
+	require.Equal(t, http.StatusAccepted, first.Code)
+
+	replay := send(h, "k1", "billing", `{"template_id": 1}`, &sends)
+	require.Equal(t, http.StatusAccepted, replay.Code)
+	require.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	require.JSONEq(t, first.Body.String(), replay.Body.String())
+	require.Equal(t, 1, sends)
+
+	// keys are scoped per client
+	require.Equal(t, http.StatusAccepted, send(h, "k1", "shipping", `{"template_id": 1}`, &sends).Code)
+	require.Equal(t, 2, sends)
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	h := newIdempotentHandler(&fakeKeys{keys: map[string]IdempotencyKey{}})
+	sends := 0
+
+	send(h, "k1", "billing", `{"template_id": 1}`, &sends)
+	w := send(h, "k1", "billing", `{"template_id": 2}`, &sends)
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Contains(t, w.Body.String(), shared.ErrIdempotencyKeyReused.Error())
+	require.Equal(t, 1, sends)
+}
+
+func TestIdempotencyConcurrentReuse(t *testing.T) {
+	keys := &fakeKeys{keys: map[string]IdempotencyKey{}}
+	h := newIdempotentHandler(keys)
+
+	// a request holding the key has not finished yet
+	_, err := h.service.BeginIdempotent(context.Background(), &IdempotencyKey{ClientID: "billing", Key: "k1", RequestHash: "h"})
+	require.NoError(t, err)
+
+	sends := 0
+	w := send(h, "k1", "billing", `{"template_id": 1}`, &sends)
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Equal(t, 0, sends)
+
+	// once its lease ran out, e.g. after a crash, the key can be used again
+	k := keys.keys["billing/k1"]
+	k.ExpiresAt = time.Now().Add(-time.Second)
+	keys.keys["billing/k1"] = k
+
+	require.Equal(t, http.StatusAccepted, send(h, "k1", "billing", `{"template_id": 1}`, &sends).Code)
+	require.Equal(t, 1, sends)
+	require.WithinDuration(t, time.Now().Add(time.Hour), keys.keys["billing/k1"].ExpiresAt, time.Minute)
+}
+
+func TestIdempotencyRejectsLongClientID(t *testing.T) {
+	h := newIdempotentHandler(&fakeKeys{keys: map[string]IdempotencyKey{}})
+	sends := 0
+
+	w := send(h, "k1", strings.Repeat("c", 65), `{"template_id": 1}`, &sends)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, 0, sends)
+}
+
+func TestIdempotencyLateRequestKeepsReclaimedKey(t *testing.T) {
+	keys := &fakeKeys{keys: map[string]IdempotencyKey{}}
+	h := newIdempotentHandler(keys)
+	ctx := context.Background()
+
+	// the first request outlives its lease and the key is reclaimed
+	first := &IdempotencyKey{ClientID: "billing", Key: "k1", RequestHash: "a"}
+	_, err := h.service.BeginIdempotent(ctx, first)
+	require.NoError(t, err)
+	k := keys.keys["billing/k1"]
+	k.ExpiresAt = time.Now().Add(-time.Second)
+	keys.keys["billing/k1"] = k
+
+	second := &IdempotencyKey{ClientID: "billing", Key: "k1", RequestHash: "b"}
+	_, err = h.service.BeginIdempotent(ctx, second)
+	require.NoError(t, err)
+
+	// neither finishing nor failing the first request touches the new owner
+	h.service.FinishIdempotent(ctx, first, &NotificationResponse{ID: 1}, nil)
+	h.service.FinishIdempotent(ctx, first, nil, shared.ErrTemplateNotFound)
+	require.Equal(t, "b", keys.keys["billing/k1"].RequestHash)
+	require.Nil(t, keys.keys["billing/k1"].Response)
+
+	h.service.FinishIdempotent(ctx, second, &NotificationResponse{ID: 2}, nil)
+	require.Equal(t, int64(2), keys.keys["billing/k1"].Response.ID)
+}
+
+func TestIdempotencyDefaultsZeroConfig(t *testing.T) {
+	keys := &fakeKeys{keys: map[string]IdempotencyKey{}}
+	s := newTestService(keys)
+	s.idempotencyCfg = &config.IdempotencyConfig{Lease: time.Second}
+	ctx := context.Background()
+
+	key := &IdempotencyKey{ClientID: "billing", Key: "k1", RequestHash: "a"}
+	_, err := s.BeginIdempotent(ctx, key)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(minIdempotencyLease), key.ExpiresAt, time.Second)
+
+	// a zero TTL keeps the default replay window rather than none
+	s.FinishIdempotent(ctx, key, &NotificationResponse{ID: 1}, nil)
+	require.WithinDuration(t, time.Now().Add(defaultIdempotencyTTL), keys.keys["billing/k1"].ExpiresAt, time.Minute)
+}
@@ -0,0 +1,137 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/template"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// SendMessage resolves the template named m.TemplateName for the channel of
+// every child notification and runs the checks of a single send on it, then
+// stores the message, its children and their outbox entries in one
+// transaction. A single invalid child fails the whole message.
+func (s *serviceImpl) SendMessage(ctx context.Context, m *Message, ns []*Notification) error {
+	if m.TemplateName == "" {
+		return shared.ErrRequiredFieldTemplateName
+	}
+	if len(ns) == 0 {
+		return shared.ErrRequiredFieldRecipients
+	}
+
+	topics := make(map[shared.Channel]string, len(ns))
+	for _, n := range ns {
+		topic, ok := s.kafkaCfg.Topics[string(n.Channel)]
+		if !ok {
+			return fmt.Errorf("kafka topic not found for channel %s", n.Channel)
+		}
+		topics[n.Channel] = topic
+
+		tpl, err := s.templateForChannel(ctx, m.TemplateName, n.Channel)
+		if err != nil {
+			return err
+		}
+
+		n.TemplateID = tpl.ID
+		if err := s.prepare(ctx, n); err != nil {
+			return err
+		}
+
+		n.Status = StatusPending
+		if n.Priority == "" {
+			n.Priority = PriorityNormal
+		}
+	}
+
+	if err := s.repo.CreateMessage(ctx, m, ns, topics); err != nil {
+		return err
+	}
+
+	m.Status = MessageInProgress
+	m.Notifications = ns
+
+	s.log.Info(ctx, "message accepted",
+		logger.Int64("message_id", m.ID),
+		logger.String("template_name", m.TemplateName),
+		logger.Int("channels", len(ns)),
+	)
+	return nil
+}
+
+// templateForChannel returns the active template with the given name for a
+// channel, preferring the most recently updated one.
+func (s *serviceImpl) templateForChannel(ctx context.Context, name string, channel shared.Channel) (*template.Template, error) {
+	active := true
+	templates, err := s.templateRepo.List(ctx, template.TemplateFilter{
+		Name:     &name,
+		Channel:  &channel,
+		IsActive: &active,
+		Limit:    1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		s.log.Warn(ctx, "no template for message channel",
+			logger.String("template_name", name),
+			logger.String("channel", string(channel)),
+		)
+		return nil, shared.ErrTemplateNotFound
+	}
+	return templates[0], nil
+}
+
+func (s *serviceImpl) GetMessage(ctx context.Context, id int64) (*Message, error) {
+	m, err := s.repo.GetMessage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := s.repo.List(ctx, NotificationFilter{MessageID: &id})
+	if err != nil {
+		return nil, err
+	}
+
+	m.Notifications = ns
+	m.Status = aggregateMessageStatus(ns)
+	return m, nil
+}
+
+// aggregateMessageStatus is in progress until every channel reached a terminal
+// status, then sent, failed, or partially sent when only some channels made it.
+// A channel that fell back counts once, with the outcome of the last
+// notification of its chain: a notification is failed in the same transaction
+// that creates its fallback, so only the fallback tells how the channel did.
+func aggregateMessageStatus(ns []*Notification) MessageStatus {
+	fellBack := make(map[int64]bool)
+	for _, n := range ns {
+		if n.ParentID != nil {
+			fellBack[*n.ParentID] = true
+		}
+	}
+
+	sent, channels := 0, 0
+	for _, n := range ns {
+		if fellBack[n.ID] {
+			continue
+		}
+		channels++
+		if !isTerminal(n.Status) {
+			return MessageInProgress
+		}
+		if n.Status == StatusSent {
+			sent++
+		}
+	}
+
+	switch sent {
+	case channels:
+		return MessageSent
+	case 0:
+		return MessageFailed
+	default:
+		return MessagePartiallySent
+	}
+}
@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	var req MessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Deterministic order, so child notification IDs follow channel names
+	channels := make([]shared.Channel, 0, len(req.Recipients))
+	for channel := range req.Recipients {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+
+	ns := make([]*Notification, 0, len(channels))
+	for _, channel := range channels {
+		n, err := mapRequestToNotification(r.Context(), SendNowRequest{
+			Channel:          channel,
+			Recipient:        req.Recipients[channel],
+			TemplateKeyValue: req.TemplateKeyValue,
+			Priority:         req.Priority,
+		}, h.users)
+		if err != nil {
+			http.Error(w, string(channel)+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		ns = append(ns, n)
+	}
+
+	m := &Message{TemplateName: req.TemplateName}
+	if err := h.service.SendMessage(r.Context(), m, ns); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusAccepted, m)
+}
+
+func (h *Handler) GetMessage(w http.ResponseWriter, r *http.Request) {
+	messageID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || messageID <= 0 {
+		http.Error(w, "invalid message ID ", http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.service.GetMessage(r.Context(), messageID)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, m)
+}
@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/template"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateMessageStatus(t *testing.T) {
+	children := func(statuses ...NotificationStatus) []*Notification {
+		ns := make([]*Notification, len(statuses))
+		for i, s := range statuses {
+			ns[i] = &Notification{Status: s}
+		}
+		return ns
+	}
+
+	require.Equal(t, MessageInProgress, aggregateMessageStatus(children(StatusSent, StatusSending)))
+	require.Equal(t, MessageInProgress, aggregateMessageStatus(children(StatusFailed, StatusScheduled)))
+	require.Equal(t, MessageSent, aggregateMessageStatus(children(StatusSent, StatusSent)))
+	require.Equal(t, MessagePartiallySent, aggregateMessageStatus(children(StatusSent, StatusFailed)))
+	require.Equal(t, MessageFailed, aggregateMessageStatus(children(StatusFailed, StatusCancelled)))
+}
+
+func TestAggregateMessageStatusFoldsFallbacks(t *testing.T) {
+	primary := int64(1)
+	chain := func(primaryStatus, fallbackStatus NotificationStatus) []*Notification {
+		return []*Notification{
+			{ID: 1, Channel: shared.ChannelSlack, Status: primaryStatus},
+			{ID: 2, Channel: shared.ChannelEmail, Status: fallbackStatus, ParentID: &primary},
+			{ID: 3, Channel: shared.ChannelInApp, Status: StatusSent},
+		}
+	}
+
+	// the slack channel reached its user by email
+	require.Equal(t, MessageSent, aggregateMessageStatus(chain(StatusFailed, StatusSent)))
+	require.Equal(t, MessageInProgress, aggregateMessageStatus(chain(StatusFailed, StatusPending)))
+	require.Equal(t, MessagePartiallySent, aggregateMessageStatus(chain(StatusFailed, StatusFailed)))
+}
+
+func (fakeTemplates) List(_ context.Context, f template.TemplateFilter) ([]*template.Template, error) {
+	return []*template.Template{{ID: 7, Name: *f.Name, Channel: *f.Channel, Body: "Hi {{.Name}}"}}, nil
+}
+
+func (r *fakeRepo) CreateMessage(_ context.Context, m *Message, ns []*Notification, _ map[shared.Channel]string) error {
+	m.ID = 1
+	for _, n := range ns {
+		n.ID = int64(len(r.notifications) + 1)
+		r.notifications[n.ID] = n
+	}
+	return nil
+}
+
+func TestSendMessageValidatesChildren(t *testing.T) {
+	repo := newFakeRepo()
+	svc := newTestService(repo)
+	svc.senders[shared.ChannelInApp] = &allowSender{allowed: "42"}
+	svc.kafkaCfg = &config.KafkaConfig{Topics: map[string]string{string(shared.ChannelInApp): "in_app"}}
+
+	blocked := inAppNotification(0)
+	blocked.Recipient.InAppUser = new(string)
+	err := svc.SendMessage(context.Background(), &Message{TemplateName: "welcome"}, []*Notification{inAppNotification(0), blocked})
+	require.ErrorIs(t, err, shared.ErrSenderNotAllowed)
+	require.Empty(t, repo.notifications)
+
+	m := &Message{TemplateName: "welcome"}
+	require.NoError(t, svc.SendMessage(context.Background(), m, []*Notification{inAppNotification(0)}))
+	require.Equal(t, MessageInProgress, m.Status)
+	require.Len(t, repo.notifications, 1)
+}
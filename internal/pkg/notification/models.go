@@ -9,7 +9,10 @@ import (
 /*
 pending → sending → sent
 pending → scheduled → sending → sent
-sending → failed
+sending → scheduled (retry with backoff)
+sending → failed (attempts exhausted)
+scheduled → cancelled
+sending → suppressed (recipient opted out)
 */
 
 type NotificationStatus string
@@ -21,25 +24,114 @@ const (
 	StatusSent       NotificationStatus = "sent"
 	StatusFailed     NotificationStatus = "failed"
 	StatusDispatched NotificationStatus = "dispatched"
+	StatusCancelled  NotificationStatus = "cancelled"
+	StatusSuppressed NotificationStatus = "suppressed"
+)
+
+// NotificationPriority decides whether a notification may be delayed by the
+// recipient's quiet hours; urgent notifications are always delivered immediately.
+type NotificationPriority string
+
+const (
+	PriorityNormal NotificationPriority = "normal"
+	PriorityUrgent NotificationPriority = "urgent"
 )
 
 type NotificationRecipient struct {
+	UserID    *string `json:"user_id,omitempty"`
 	Email     *string `json:"email,omitempty"`
 	SlackUser *string `json:"slack,omitempty"`
 	InAppUser *string `json:"in_app,omitempty"`
+	Webhook   *string `json:"webhook,omitempty"`
+	Phone     *string `json:"phone,omitempty"`
+
+	// Email only options, see EmailOptions. Headers are also set on webhook
+	// requests, see WebhookOptions.
+	CC      []string          `json:"cc,omitempty"`
+	BCC     []string          `json:"bcc,omitempty"`
+	ReplyTo *string           `json:"reply_to,omitempty"`
+	From    *string           `json:"from,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Slack only options, see SlackOptions.
+	ThreadOf *int64 `json:"thread_of,omitempty"`
+	UpdateOf *int64 `json:"update_of,omitempty"`
 }
 
 type Notification struct {
 	ID               int64                 `json:"id"`
+	BatchID          *int64                `json:"batch_id,omitempty"`
+	MessageID        *int64                `json:"message_id,omitempty"`
+	ParentID         *int64                `json:"parent_id,omitempty"`
 	Channel          shared.Channel        `json:"channel"`
 	TemplateID       int64                 `json:"template_id"`
 	Recipient        NotificationRecipient `json:"recipient"`
 	TemplateKeyValue map[string]any        `json:"template_key_value"`
+	Fallback         []FallbackStep        `json:"fallback,omitempty"`
 	Status           NotificationStatus    `json:"status"`
-	ScheduledAt      *time.Time            `json:"scheduled_at,omitempty"`
-	SentAt           *time.Time            `json:"sent_at,omitempty"`
-	CreatedAt        time.Time             `json:"created_at"`
-	UpdatedAt        time.Time             `json:"updated_at"`
+	StatusReason     string                `json:"status_reason,omitempty"`
+	// ProviderChannel and ProviderMessageID identify the delivered message,
+	// e.g. the Slack channel and ts.
+	ProviderChannel   string               `json:"provider_channel,omitempty"`
+	ProviderMessageID string               `json:"provider_message_id,omitempty"`
+	Priority          NotificationPriority `json:"priority"`
+	Attempts          int                  `json:"attempts"`
+	ScheduledAt       *time.Time           `json:"scheduled_at,omitempty"`
+	SentAt            *time.Time           `json:"sent_at,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+
+	// DigestKey groups notifications held for the same user digest. It is
+	// only populated by GetByID.
+	DigestKey string `json:"-"`
+
+	// Attachments are only loaded for delivery.
+	Attachments []Attachment `json:"-"`
+
+	// Headers are extra email headers set at delivery time, such as
+	// List-Unsubscribe. They are not persisted.
+	Headers map[string]string `json:"-"`
+
+	// Reference is the earlier message a Slack send replies to or replaces,
+	// resolved from ThreadOf or UpdateOf at delivery time.
+	Reference *MessageReference `json:"-"`
+
+	// Chain lists the whole fallback chain this notification belongs to, in
+	// delivery order. It is only populated by GetByID.
+	Chain []ChainLink `json:"chain,omitempty"`
+}
+
+// FallbackStep is a channel to try, in order, once delivery on the previous
+// channel has failed for good.
+type FallbackStep struct {
+	Channel    shared.Channel        `json:"channel"`
+	TemplateID int64                 `json:"template_id"`
+	Recipient  NotificationRecipient `json:"recipient"`
+}
+
+type FallbackRequest struct {
+	Channel shared.Channel `json:"channel"`
+	// TemplateID defaults to the template with the primary template's name on Channel
+	TemplateID int64             `json:"template_id,omitempty"`
+	Recipient  map[string]string `json:"recipient"`
+}
+
+type ChainLink struct {
+	ID       int64              `json:"id"`
+	Channel  shared.Channel     `json:"channel"`
+	Status   NotificationStatus `json:"status"`
+	Attempts int                `json:"attempts"`
+}
+
+type NotificationAttempt struct {
+	ID               int64     `json:"id"`
+	NotificationID   int64     `json:"notification_id"`
+	Attempt          int       `json:"attempt"`
+	Sender           string    `json:"sender"`
+	Error            string    `json:"error,omitempty"`
+	ProviderResponse string    `json:"provider_response,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
 }
 
 type NotificationScheduled struct {
@@ -48,8 +140,10 @@ type NotificationScheduled struct {
 }
 
 type NotificationFilter struct {
-	Channel *shared.Channel
-	Status  *NotificationStatus
+	Channel   *shared.Channel
+	Status    *NotificationStatus
+	MessageID *int64
+	ParentID  *int64
 }
 
 type SendNowRequest struct {
@@ -59,14 +153,266 @@ type SendNowRequest struct {
 
 	Recipient        map[string]string `json:"recipient"`
 	TemplateKeyValue map[string]any    `json:"template_key_value"`
+
+	Priority NotificationPriority `json:"priority,omitempty"`
+
+	Fallback []FallbackRequest `json:"fallback,omitempty"`
+
+	Attachments []AttachmentRequest `json:"attachments,omitempty"`
+
+	Email *EmailOptions `json:"email,omitempty"`
+	Slack *SlackOptions `json:"slack,omitempty"`
+
+	Webhook *WebhookOptions `json:"webhook,omitempty"`
+}
+
+// WebhookOptions add headers to the webhook request, e.g. an Authorization
+// the endpoint expects. Content-Type, Host and the X-Notify- headers are set
+// by the sender and cannot be overridden.
+type WebhookOptions struct {
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SlackOptions point a Slack send at an earlier Slack notification: ThreadOf
+// replies in its thread, UpdateOf edits its message in place. At most one
+// may be set.
+type SlackOptions struct {
+	ThreadOf *int64 `json:"thread_of,omitempty"`
+	UpdateOf *int64 `json:"update_of,omitempty"`
+}
+
+// ReferenceMode is how a send relates to the message it references.
+type ReferenceMode string
+
+const (
+	ReferenceThread ReferenceMode = "thread"
+	ReferenceUpdate ReferenceMode = "update"
+)
+
+// MessageReference is a delivered message that a send replies to or updates.
+type MessageReference struct {
+	Mode      ReferenceMode
+	Channel   string
+	MessageID string
 }
 
+// EmailOptions extend an email recipient. From may carry a display name, but
+// its address must be one of the configured sender identities; Headers only
+// accepts X- headers.
+type EmailOptions struct {
+	CC      []string          `json:"cc,omitempty"`
+	BCC     []string          `json:"bcc,omitempty"`
+	ReplyTo string            `json:"reply_to,omitempty"`
+	From    string            `json:"from,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// AttachmentRequest carries either base64 content or a URL fetched at send
+// time. Inline attachments are referenced from the HTML body as cid:<content_id>.
+type AttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     string `json:"content,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Inline      bool   `json:"inline,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+// Attachment is an email attachment stored with its notification. Content is
+// empty for URL attachments until they are fetched for delivery.
+type Attachment struct {
+	ID          int64  `json:"id,omitempty"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	URL         string `json:"url,omitempty"`
+	Inline      bool   `json:"inline,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Content     []byte `json:"-"`
+}
+
+// ScheduleRequest takes either an absolute scheduled_at or a wall clock
+// local_time (2006-01-02T15:04:05, no offset) interpreted in timezone.
 type ScheduleRequest struct {
 	SendNowRequest
 	ScheduledAt time.Time `json:"scheduled_at"`
+	LocalTime   string    `json:"local_time,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+}
+
+// QuietHours is a daily window, in the recipient's timezone, during which
+// non-urgent notifications to that recipient are held back. A window whose
+// start is after its end wraps past midnight.
+type QuietHours struct {
+	Channel   shared.Channel `json:"channel"`
+	Recipient string         `json:"recipient"`
+	Timezone  string         `json:"timezone"`
+	Start     string         `json:"start"`
+	End       string         `json:"end"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type QuietHoursRequest struct {
+	Timezone string `json:"timezone"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
+type RecurringStatus string
+
+const (
+	RecurringActive    RecurringStatus = "active"
+	RecurringPaused    RecurringStatus = "paused"
+	RecurringCompleted RecurringStatus = "completed"
+)
+
+type RecurringSchedule struct {
+	ID               int64                 `json:"id"`
+	CronExpr         string                `json:"cron"`
+	Timezone         string                `json:"timezone"`
+	Channel          shared.Channel        `json:"channel"`
+	TemplateID       int64                 `json:"template_id"`
+	Recipient        NotificationRecipient `json:"recipient"`
+	TemplateKeyValue map[string]any        `json:"template_key_value"`
+	Status           RecurringStatus       `json:"status"`
+	StartAt          *time.Time            `json:"start_at,omitempty"`
+	EndAt            *time.Time            `json:"end_at,omitempty"`
+	NextRunAt        *time.Time            `json:"next_run_at,omitempty"`
+	LastRunAt        *time.Time            `json:"last_run_at,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+}
+
+type RecurringScheduleRequest struct {
+	SendNowRequest
+	Cron     string     `json:"cron"`
+	Timezone string     `json:"timezone"`
+	StartAt  *time.Time `json:"start_at"`
+	EndAt    *time.Time `json:"end_at"`
+}
+
+type RescheduleRequest struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// MessageStatus aggregates the statuses of a message's per-channel notifications.
+type MessageStatus string
+
+const (
+	MessageInProgress    MessageStatus = "in_progress"
+	MessageSent          MessageStatus = "sent"
+	MessagePartiallySent MessageStatus = "partially_sent"
+	MessageFailed        MessageStatus = "failed"
+)
+
+// Message is one logical notification fanned out to several channels, each
+// delivered by its own child notification using that channel's template.
+type Message struct {
+	ID            int64           `json:"id"`
+	TemplateName  string          `json:"template_name"`
+	Status        MessageStatus   `json:"status"`
+	Notifications []*Notification `json:"notifications"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// MessageRequest addresses one recipient per channel; the keys of recipients
+// select the channels and each value uses the single channel recipient format.
+type MessageRequest struct {
+	TemplateName     string                               `json:"template_name"`
+	Recipients       map[shared.Channel]map[string]string `json:"recipients"`
+	TemplateKeyValue map[string]any                       `json:"template_key_value"`
+	Priority         NotificationPriority                 `json:"priority,omitempty"`
+}
+
+// NotificationBatch groups the notifications created by one bulk send request.
+// Counts is filled when the batch is read back and tracks delivery progress.
+type NotificationBatch struct {
+	ID         int64                      `json:"id"`
+	Channel    shared.Channel             `json:"channel"`
+	TemplateID int64                      `json:"template_id"`
+	Total      int                        `json:"total"`
+	Accepted   int                        `json:"accepted"`
+	Counts     map[NotificationStatus]int `json:"counts,omitempty"`
+	Completed  bool                       `json:"completed"`
+	CreatedAt  time.Time                  `json:"created_at"`
+}
+
+// BatchRecipient is one entry of a bulk send; its template_key_value is merged
+// over the batch level values.
+type BatchRecipient struct {
+	Recipient        map[string]string `json:"recipient"`
+	TemplateKeyValue map[string]any    `json:"template_key_value,omitempty"`
+}
+
+type BatchSendRequest struct {
+	Channel          shared.Channel       `json:"channel"`
+	TemplateID       int64                `json:"template_id"`
+	TemplateKeyValue map[string]any       `json:"template_key_value"`
+	Priority         NotificationPriority `json:"priority,omitempty"`
+	Recipients       []BatchRecipient     `json:"recipients"`
+}
+
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	ID     int64  `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchSendResponse struct {
+	BatchID  int64             `json:"batch_id"`
+	Total    int               `json:"total"`
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Items    []BatchItemResult `json:"items"`
 }
 
 type NotificationResponse struct {
 	ID     int64  `json:"id"`
 	Status string `json:"status"`
 }
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	// OutboxDead rows failed to publish too often and are left for an operator.
+	OutboxDead OutboxStatus = "dead"
+)
+
+// OutboxMessage is a Kafka message written in the same transaction as its
+// notification and published later by the OutboxRelay.
+type OutboxMessage struct {
+	ID             int64
+	NotificationID int64
+	Topic          string
+	Key            string
+	Payload        []byte
+	Attempts       int
+}
+
+// IdempotencyKey scopes a caller supplied Idempotency-Key to a client and remembers
+// the response of the first request made with it.
+type IdempotencyKey struct {
+	ClientID    string
+	Key         string
+	RequestHash string
+	Response    *NotificationResponse
+	ExpiresAt   time.Time
+}
+
+type DeadLetterReplayRequest struct {
+	Partition  int32 `json:"partition"`
+	FromOffset int64 `json:"from_offset"`
+	ToOffset   int64 `json:"to_offset"`
+}
+
+type DeadLetterReplayResponse struct {
+	Replayed int `json:"replayed"`
+	// Reset counts the failed notifications moved back to pending.
+	Reset int `json:"reset"`
+	// Skipped lists the topic/partition/offset of messages that do not hold
+	// a notification ID and were not replayed.
+	Skipped []string `json:"skipped,omitempty"`
+}
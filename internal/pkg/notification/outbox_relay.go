@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/kafka"
+	"github.com/ckshitij/notify-srv/internal/logger"
+)
+
+const (
+	// outboxLease is how long a relay owns the rows it claimed; rows of a
+	// relay that died are published by another one after it.
+	outboxLease = 30 * time.Second
+	// outboxMaxAttempts failed publishes turn a row dead.
+	outboxMaxAttempts = 10
+)
+
+// outboxBackoff spaces the publish attempts of a failing row, so it does not
+// hold up the rows behind it.
+var outboxBackoff = config.RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Minute}
+
+// OutboxPublisher sends messages and returns the errors by message index.
+type OutboxPublisher interface {
+	SendMessages(messages []kafka.Message) map[int]error
+}
+
+// OutboxRelay publishes outbox rows to their channel topics and marks them done.
+// Each relay instance claims the rows it publishes. Delivery is at-least-once:
+// a crash between publish and mark republishes the message, which Process
+// ignores unless it acquires the notification for sending.
+type OutboxRelay struct {
+	repo      Repository
+	log       logger.Logger
+	interval  time.Duration
+	batch     int
+	publisher OutboxPublisher
+}
+
+func NewOutboxRelay(repo Repository, log logger.Logger, interval time.Duration, batch int, publisher OutboxPublisher) *OutboxRelay {
+	return &OutboxRelay{repo, log, interval, batch, publisher}
+}
+
+func (o *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	o.log.Info(ctx, "outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			o.log.Info(ctx, "outbox relay stopped")
+			return
+
+		case <-ticker.C:
+			// Drain backlogs such as large batches without waiting for the next tick
+			for o.tick(ctx) && ctx.Err() == nil {
+			}
+		}
+	}
+}
+
+// tick publishes one batch of pending outbox messages and reports whether a
+// full batch went out cleanly, in which case more are likely waiting.
+func (o *OutboxRelay) tick(ctx context.Context) bool {
+	messages, err := o.repo.ClaimPendingOutbox(ctx, o.batch, outboxLease)
+	if err != nil {
+		o.log.Error(ctx, "failed to claim pending outbox messages", logger.Error(err))
+		return false
+	}
+
+	if len(messages) == 0 {
+		return false
+	}
+
+	records := make([]kafka.Message, len(messages))
+	for i, m := range messages {
+		records[i] = kafka.Message{Topic: m.Topic, Key: m.Key, Value: m.Payload}
+	}
+	failed := o.publisher.SendMessages(records)
+
+	published := make([]int64, 0, len(messages))
+	for i, m := range messages {
+		if err, ok := failed[i]; ok {
+			o.markFailed(ctx, m, err)
+			continue
+		}
+		published = append(published, m.ID)
+	}
+
+	if err := o.repo.MarkOutboxPublished(ctx, published); err != nil {
+		o.log.Error(ctx, "failed to mark outbox messages published", logger.Int("count", len(published)), logger.Error(err))
+		return false
+	}
+
+	return len(failed) == 0 && len(messages) == o.batch
+}
+
+// markFailed backs the message off, or gives up on it after outboxMaxAttempts.
+func (o *OutboxRelay) markFailed(ctx context.Context, m OutboxMessage, cause error) {
+	attempts := m.Attempts + 1
+	dead := attempts >= outboxMaxAttempts
+	retryAt := time.Now().Add(retryDelay(outboxBackoff, attempts))
+
+	fields := []logger.Field{
+		logger.Int64("outbox_id", m.ID),
+		logger.Int64("notification_id", m.NotificationID),
+		logger.String("topic", m.Topic),
+		logger.Int("attempts", attempts),
+		logger.Error(cause),
+	}
+	if dead {
+		o.log.Error(ctx, "failed to publish outbox message, giving up", fields...)
+	} else {
+		o.log.Warn(ctx, "failed to publish outbox message", fields...)
+	}
+
+	if err := o.repo.MarkOutboxFailed(ctx, m.ID, cause.Error(), retryAt, dead); err != nil {
+		o.log.Error(ctx, "failed to mark outbox message failed", logger.Int64("outbox_id", m.ID), logger.Error(err))
+	}
+}
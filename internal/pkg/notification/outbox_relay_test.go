@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/kafka"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxFailure struct {
+	retryAt time.Time
+	dead    bool
+}
+
+// fakeOutbox leases rows the way the store does.
+type fakeOutbox struct {
+	Repository
+	rows      []OutboxMessage
+	claimed   map[int64]time.Time
+	published []int64
+	failed    map[int64]outboxFailure
+}
+
+func newFakeOutbox(rows ...OutboxMessage) *fakeOutbox {
+	return &fakeOutbox{rows: rows, claimed: map[int64]time.Time{}, failed: map[int64]outboxFailure{}}
+}
+
+func (f *fakeOutbox) ClaimPendingOutbox(_ context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	var out []OutboxMessage
+	for _, m := range f.rows {
+		if len(out) == limit {
+			break
+		}
+		if time.Now().Before(f.claimed[m.ID]) || f.failed[m.ID].dead || slices.Contains(f.published, m.ID) {
+			continue
+		}
+		f.claimed[m.ID] = time.Now().Add(lease)
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (f *fakeOutbox) MarkOutboxPublished(_ context.Context, ids []int64) error {
+	f.published = append(f.published, ids...)
+	return nil
+}
+
+func (f *fakeOutbox) MarkOutboxFailed(_ context.Context, id int64, _ string, retryAt time.Time, dead bool) error {
+	f.failed[id] = outboxFailure{retryAt, dead}
+	f.claimed[id] = retryAt
+	return nil
+}
+
+// fakePublisher fails every message of the given notifications.
+type fakePublisher struct {
+	failing map[int64]bool
+	sent    []kafka.Message
+}
+
+func (p *fakePublisher) SendMessages(messages []kafka.Message) map[int]error {
+	failed := map[int]error{}
+	for i, m := range messages {
+		if p.failing[int64(m.Value[0]-'0')] {
+			failed[i] = errors.New("broker unavailable")
+			continue
+		}
+		p.sent = append(p.sent, m)
+	}
+	return failed
+}
+
+func outboxRow(id int64, attempts int) OutboxMessage {
+	return OutboxMessage{ID: id, NotificationID: id, Topic: "notifications-email", Payload: []byte{byte('0' + id)}, Attempts: attempts}
+}
+
+func TestOutboxRelayFailedRowDoesNotBlock(t *testing.T) {
+	repo := newFakeOutbox(outboxRow(1, 0), outboxRow(2, 0), outboxRow(3, 0))
+	publisher := &fakePublisher{failing: map[int64]bool{1: true}}
+	relay := NewOutboxRelay(repo, nopLogger{}, time.Second, 2, publisher)
+
+	relay.tick(context.Background())
+	require.Equal(t, []int64{2}, repo.published)
+	require.False(t, repo.failed[1].dead)
+	require.True(t, repo.failed[1].retryAt.After(time.Now()))
+
+	// the failed head row backs off, the next tick moves past it
+	relay.tick(context.Background())
+	require.Equal(t, []int64{2, 3}, repo.published)
+}
+
+func TestOutboxRelayClaimsRows(t *testing.T) {
+	repo := newFakeOutbox(outboxRow(1, 0), outboxRow(2, 0))
+	relay := NewOutboxRelay(repo, nopLogger{}, time.Second, 1, &fakePublisher{})
+
+	// another relay instance holds row 1
+	messages, err := repo.ClaimPendingOutbox(context.Background(), 1, outboxLease)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), messages[0].ID)
+
+	relay.tick(context.Background())
+	relay.tick(context.Background())
+	require.Equal(t, []int64{2}, repo.published)
+}
+
+func TestOutboxRelayGivesUp(t *testing.T) {
+	repo := newFakeOutbox(outboxRow(1, outboxMaxAttempts-1))
+	relay := NewOutboxRelay(repo, nopLogger{}, time.Second, 10, &fakePublisher{failing: map[int64]bool{1: true}})
+
+	relay.tick(context.Background())
+	require.True(t, repo.failed[1].dead)
+	require.Empty(t, repo.published)
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// Reasons recorded in status_reason when a notification is held back or
+// dropped instead of being delivered.
+const (
+	reasonQuietHours = "quiet_hours"
+	reasonDigest     = "digest"
+	reasonOptedOut   = "opted_out"
+	// followed by the suppression reason, e.g. suppression_list:hard_bounce
+	reasonSuppressionList = "suppression_list"
+)
+
+// UserPreferences resolves how and, by their timezone, when a user wants to
+// receive notifications.
+type UserPreferences interface {
+	GetByID(ctx context.Context, userID string) (*user.User, error)
+	ResolvePreference(ctx context.Context, userID, category string, channel shared.Channel) (user.PreferenceMode, error)
+}
+
+// applyPreferences honours the channel preferences of a notification sent to
+// a user_id recipient: opted out categories are suppressed and digest ones are
+// held for the user's next digest. Notifications sent to a raw address carry
+// no user and are not checked. Like quiet hours it is best effort, a failed
+// lookup is logged and the send goes ahead.
+func (s *serviceImpl) applyPreferences(ctx context.Context, n *Notification) bool {
+	if s.preferences == nil || n.Recipient.UserID == nil {
+		return false
+	}
+	userID := *n.Recipient.UserID
+
+	tpl, err := s.templateRepo.GetByID(ctx, n.TemplateID)
+	if err != nil {
+		s.log.Warn(ctx, "failed to load template category, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+
+	mode, err := s.preferences.ResolvePreference(ctx, userID, tpl.Category, n.Channel)
+	if err != nil {
+		s.log.Warn(ctx, "failed to resolve user preferences, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+	switch mode {
+	case user.PreferenceDigest:
+		// already held, it is being delivered with its digest
+		if n.DigestKey != "" {
+			return false
+		}
+		return s.holdForDigest(ctx, n, userID)
+	case user.PreferenceDisabled:
+	default:
+		return false
+	}
+
+	if err := s.repo.MarkSuppressed(ctx, n.ID, reasonOptedOut); err != nil {
+		s.log.Warn(ctx, "failed to suppress notification, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+	s.log.Info(ctx, "notification suppressed by user preference",
+		logger.Int64("notificationID", n.ID),
+		logger.String("userID", userID),
+		logger.String("category", tpl.Category),
+	)
+	return true
+}
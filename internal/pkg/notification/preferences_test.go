@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePreferences user.PreferenceMode
+
+func (fakePreferences) GetByID(context.Context, string) (*user.User, error) {
+	return nil, shared.ErrRecordNotFound
+}
+
+func (p fakePreferences) ResolvePreference(context.Context, string, string, shared.Channel) (user.PreferenceMode, error) {
+	return user.PreferenceMode(p), nil
+}
+
+func (r *fakeRepo) MarkSuppressed(_ context.Context, id int64, reason string) error {
+	r.statuses[id] = StatusSuppressed
+	r.notifications[id].StatusReason = reason
+	return nil
+}
+
+func TestApplyPreferences(t *testing.T) {
+	n := inAppNotification(1)
+	userID := "42"
+	n.Recipient.UserID = &userID
+	repo := newFakeRepo(n)
+	svc := newTestService(repo)
+
+	svc.preferences = fakePreferences(user.PreferenceEnabled)
+	require.False(t, svc.applyPreferences(context.Background(), n))
+	require.Equal(t, StatusPending, repo.statuses[1])
+
+	svc.preferences = fakePreferences(user.PreferenceDisabled)
+	require.True(t, svc.applyPreferences(context.Background(), n))
+	require.Equal(t, StatusSuppressed, repo.statuses[1])
+	require.Equal(t, reasonOptedOut, n.StatusReason)
+}
+
+func TestApplyPreferencesIgnoresRawAddress(t *testing.T) {
+	n := inAppNotification(1)
+	repo := newFakeRepo(n)
+	svc := newTestService(repo)
+	svc.preferences = fakePreferences(user.PreferenceDisabled)
+
+	require.False(t, svc.applyPreferences(context.Background(), n))
+	require.Equal(t, StatusPending, repo.statuses[1])
+}
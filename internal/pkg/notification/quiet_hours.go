@@ -0,0 +1,219 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+const (
+	localTimeLayout = "2006-01-02T15:04:05"
+	clockLayout     = "15:04"
+)
+
+// resolveScheduledAt returns the absolute send time of a schedule request. A
+// local_time is interpreted in timezone; wall clock times skipped by a DST
+// change are normalized forward by the time package.
+func resolveScheduledAt(req ScheduleRequest) (time.Time, error) {
+	if req.LocalTime == "" {
+		if req.Timezone != "" {
+			return time.Time{}, shared.ErrInvalidLocalTime
+		}
+		return req.ScheduledAt, nil
+	}
+	if !req.ScheduledAt.IsZero() {
+		return time.Time{}, shared.ErrConflictingScheduleTime
+	}
+
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil || req.Timezone == "" {
+		return time.Time{}, shared.ErrInvalidTimezone
+	}
+
+	when, err := time.ParseInLocation(localTimeLayout, req.LocalTime, loc)
+	if err != nil {
+		return time.Time{}, shared.ErrInvalidLocalTime
+	}
+	return when.UTC(), nil
+}
+
+// address returns the channel specific recipient that quiet hours are keyed by.
+func (r NotificationRecipient) address(channel shared.Channel) string {
+	var v *string
+	switch channel {
+	case shared.ChannelEmail:
+		v = r.Email
+	case shared.ChannelSlack:
+		v = r.SlackUser
+	case shared.ChannelInApp:
+		v = r.InAppUser
+	case shared.ChannelSMS:
+		v = r.Phone
+	}
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// validateQuietHours allows an empty timezone: the window then follows the
+// timezone of the user a notification is sent to, or UTC.
+func validateQuietHours(q *QuietHours) error {
+	if q.Recipient == "" {
+		return shared.ErrInvalidQuietHours
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return shared.ErrInvalidTimezone
+	}
+	start, err := time.Parse(clockLayout, q.Start)
+	if err != nil {
+		return shared.ErrInvalidQuietHours
+	}
+	end, err := time.Parse(clockLayout, q.End)
+	if err != nil || start.Equal(end) {
+		return shared.ErrInvalidQuietHours
+	}
+	return nil
+}
+
+// quietUntil reports whether t falls inside the quiet window and, if so, when
+// the window ends. The window is evaluated on the recipient's local calendar.
+func quietUntil(q *QuietHours, t time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start, err1 := time.Parse(clockLayout, q.Start)
+	end, err2 := time.Parse(clockLayout, q.End)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+
+	local := t.In(loc)
+	at := func(day time.Time, clock time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	startToday, endToday := at(local, start), at(local, end)
+	switch {
+	case startToday.Before(endToday):
+		// e.g. 13:00-15:00
+		if !local.Before(startToday) && local.Before(endToday) {
+			return endToday.UTC(), true
+		}
+	case !local.Before(startToday):
+		// e.g. 22:00-07:00, after 22:00 the window ends tomorrow
+		return at(local.AddDate(0, 0, 1), end).UTC(), true
+	case local.Before(endToday):
+		// e.g. 22:00-07:00, before 07:00 the window ends today
+		return endToday.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func (s *serviceImpl) SetQuietHours(ctx context.Context, q *QuietHours) error {
+	if err := validateQuietHours(q); err != nil {
+		return err
+	}
+	return s.repo.UpsertQuietHours(ctx, q)
+}
+
+func (s *serviceImpl) GetQuietHours(ctx context.Context, channel shared.Channel, recipient string) (*QuietHours, error) {
+	return s.repo.GetQuietHours(ctx, channel, recipient)
+}
+
+func (s *serviceImpl) DeleteQuietHours(ctx context.Context, channel shared.Channel, recipient string) error {
+	deleted, err := s.repo.DeleteQuietHours(ctx, channel, recipient)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return shared.ErrRecordNotFound
+	}
+	return nil
+}
+
+// deferForQuietHours moves a non-urgent notification that is being sent inside
+// its recipient's quiet hours back to scheduled at the end of the window. Quiet
+// hours are best effort: a failed lookup is logged and the send goes ahead.
+func (s *serviceImpl) deferForQuietHours(ctx context.Context, n *Notification) bool {
+	if n.Priority == PriorityUrgent {
+		return false
+	}
+
+	q, err := s.repo.GetQuietHours(ctx, n.Channel, n.Recipient.address(n.Channel))
+	if err != nil {
+		if !errors.Is(err, shared.ErrRecordNotFound) {
+			s.log.Warn(ctx, "failed to load quiet hours, sending anyway",
+				logger.Int64("notificationID", n.ID),
+				logger.Error(err),
+			)
+		}
+		return false
+	}
+
+	if q.Timezone == "" {
+		window := *q
+		window.Timezone = s.userTimezone(ctx, n)
+		q = &window
+	}
+
+	until, quiet := quietUntil(q, time.Now())
+	if !quiet {
+		return false
+	}
+
+	deferred, err := s.repo.DeferSending(ctx, n.ID, until, reasonQuietHours)
+	if err != nil {
+		s.log.Warn(ctx, "failed to defer notification for quiet hours, sending anyway",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return false
+	}
+	if !deferred {
+		s.leftSending(ctx, n)
+		return true
+	}
+
+	s.log.Info(ctx, "notification deferred by recipient quiet hours",
+		logger.Int64("notificationID", n.ID),
+		logger.Any("scheduled_at", until),
+	)
+	return true
+}
+
+// userTimezone returns the directory timezone of a notification's user_id
+// recipient, or "" (UTC) for a raw address or a failed lookup.
+func (s *serviceImpl) userTimezone(ctx context.Context, n *Notification) string {
+	if s.preferences == nil || n.Recipient.UserID == nil {
+		return ""
+	}
+	u, err := s.preferences.GetByID(ctx, *n.Recipient.UserID)
+	if err != nil {
+		s.log.Warn(ctx, "failed to load user timezone, using UTC",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(err),
+		)
+		return ""
+	}
+	return u.Timezone
+}
+
+// leftSending logs a notification that could not be deferred because it left
+// the sending state while being processed, e.g. because the scheduler took it
+// for stuck. Whoever moved it owns it now, so this delivery stops without
+// sending to avoid a duplicate.
+func (s *serviceImpl) leftSending(ctx context.Context, n *Notification) {
+	current := "unknown"
+	if latest, err := s.repo.GetByID(ctx, n.ID); err == nil {
+		current = string(latest.Status)
+	}
+	s.log.Warn(ctx, "notification left the sending state before it could be deferred, skipping",
+		logger.Int64("notificationID", n.ID),
+		logger.String("status", current),
+	)
+}
@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) SetQuietHours(w http.ResponseWriter, r *http.Request) {
+	channel, recipient, ok := quietHoursParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req QuietHoursRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	q := &QuietHours{
+		Channel:   channel,
+		Recipient: recipient,
+		Timezone:  req.Timezone,
+		Start:     req.Start,
+		End:       req.End,
+	}
+
+	if err := h.service.SetQuietHours(r.Context(), q); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, q)
+}
+
+func (h *Handler) GetQuietHours(w http.ResponseWriter, r *http.Request) {
+	channel, recipient, ok := quietHoursParams(w, r)
+	if !ok {
+		return
+	}
+
+	q, err := h.service.GetQuietHours(r.Context(), channel, recipient)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, q)
+}
+
+func (h *Handler) DeleteQuietHours(w http.ResponseWriter, r *http.Request) {
+	channel, recipient, ok := quietHoursParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteQuietHours(r.Context(), channel, recipient); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func quietHoursParams(w http.ResponseWriter, r *http.Request) (shared.Channel, string, bool) {
+	channel := shared.Channel(chi.URLParam(r, "channel"))
+	switch channel {
+	case shared.ChannelEmail, shared.ChannelSlack, shared.ChannelInApp, shared.ChannelSMS:
+	default:
+		http.Error(w, "unsupported channel", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	recipient, err := url.PathUnescape(chi.URLParam(r, "recipient"))
+	if err != nil || recipient == "" {
+		http.Error(w, "invalid recipient", http.StatusBadRequest)
+		return "", "", false
+	}
+	return channel, recipient, true
+}
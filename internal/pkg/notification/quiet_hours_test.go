@@ -0,0 +1,140 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveScheduledAtLocalTime(t *testing.T) {
+	when, err := resolveScheduledAt(ScheduleRequest{LocalTime: "2026-07-01T09:00:00", Timezone: "America/New_York"})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 7, 1, 13, 0, 0, 0, time.UTC), when)
+
+	absolute := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	when, err = resolveScheduledAt(ScheduleRequest{ScheduledAt: absolute})
+	require.NoError(t, err)
+	require.Equal(t, absolute, when)
+}
+
+func TestResolveScheduledAtInvalid(t *testing.T) {
+	_, err := resolveScheduledAt(ScheduleRequest{LocalTime: "2026-07-01T09:00:00"})
+	require.ErrorIs(t, err, shared.ErrInvalidTimezone)
+
+	_, err = resolveScheduledAt(ScheduleRequest{LocalTime: "2026-07-01T09:00:00Z", Timezone: "UTC"})
+	require.ErrorIs(t, err, shared.ErrInvalidLocalTime)
+
+	_, err = resolveScheduledAt(ScheduleRequest{ScheduledAt: time.Now(), LocalTime: "2026-07-01T09:00:00", Timezone: "UTC"})
+	require.ErrorIs(t, err, shared.ErrConflictingScheduleTime)
+}
+
+func TestQuietUntilWrapsMidnight(t *testing.T) {
+	q := &QuietHours{Timezone: "Europe/Berlin", Start: "22:00", End: "07:00"}
+
+	// 23:30 Berlin (CEST, UTC+2) ends at 07:00 the next morning
+	until, quiet := quietUntil(q, time.Date(2026, 7, 1, 21, 30, 0, 0, time.UTC))
+	require.True(t, quiet)
+	require.Equal(t, time.Date(2026, 7, 2, 5, 0, 0, 0, time.UTC), until)
+
+	// 06:00 Berlin ends at 07:00 the same morning
+	until, quiet = quietUntil(q, time.Date(2026, 7, 2, 4, 0, 0, 0, time.UTC))
+	require.True(t, quiet)
+	require.Equal(t, time.Date(2026, 7, 2, 5, 0, 0, 0, time.UTC), until)
+
+	// 12:00 Berlin is outside the window
+	_, quiet = quietUntil(q, time.Date(2026, 7, 2, 10, 0, 0, 0, time.UTC))
+	require.False(t, quiet)
+}
+
+func TestQuietUntilSameDay(t *testing.T) {
+	q := &QuietHours{Timezone: "UTC", Start: "13:00", End: "15:00"}
+
+	until, quiet := quietUntil(q, time.Date(2026, 7, 1, 13, 0, 0, 0, time.UTC))
+	require.True(t, quiet)
+	require.Equal(t, time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC), until)
+
+	_, quiet = quietUntil(q, time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC))
+	require.False(t, quiet)
+}
+
+func TestValidateQuietHours(t *testing.T) {
+	require.NoError(t, validateQuietHours(&QuietHours{Recipient: "a@example.com", Timezone: "UTC", Start: "22:00", End: "07:00"}))
+	require.ErrorIs(t, validateQuietHours(&QuietHours{Recipient: "a@example.com", Timezone: "UTC", Start: "25:00", End: "07:00"}), shared.ErrInvalidQuietHours)
+	require.ErrorIs(t, validateQuietHours(&QuietHours{Recipient: "a@example.com", Timezone: "UTC", Start: "07:00", End: "07:00"}), shared.ErrInvalidQuietHours)
+	require.ErrorIs(t, validateQuietHours(&QuietHours{Recipient: "a@example.com", Timezone: "Nowhere/Land", Start: "22:00", End: "07:00"}), shared.ErrInvalidTimezone)
+	// follows the user's timezone
+	require.NoError(t, validateQuietHours(&QuietHours{Recipient: "a@example.com", Start: "22:00", End: "07:00"}))
+}
+
+func TestDeferForQuietHours(t *testing.T) {
+	now := time.Now().UTC()
+	quiet := &QuietHours{Timezone: "UTC", Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")}
+
+	n := inAppNotification(1)
+	n.Status = StatusSending
+	repo := newFakeRepo(n)
+	repo.quietHours = quiet
+	s := newTestService(repo)
+
+	require.True(t, s.deferForQuietHours(context.Background(), n))
+	require.Equal(t, StatusScheduled, repo.statuses[1])
+	require.Contains(t, repo.retries, int64(1))
+}
+
+func TestDeferForQuietHoursNotSending(t *testing.T) {
+	now := time.Now().UTC()
+	quiet := &QuietHours{Timezone: "UTC", Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")}
+
+	// the scheduler took the notification over in the meantime
+	n := inAppNotification(1)
+	n.Status = StatusDispatched
+	repo := newFakeRepo(n)
+	repo.quietHours = quiet
+	s := newTestService(repo)
+
+	require.True(t, s.deferForQuietHours(context.Background(), n))
+	require.Equal(t, StatusDispatched, repo.statuses[1])
+	require.Empty(t, repo.retries)
+}
+
+// userDirectory serves the timezone of every user from the directory.
+type userDirectory struct {
+	fakePreferences
+	timezone string
+}
+
+func (d userDirectory) GetByID(_ context.Context, userID string) (*user.User, error) {
+	return &user.User{ID: userID, Timezone: d.timezone}, nil
+}
+
+func TestDeferForQuietHoursInUserTimezone(t *testing.T) {
+	// a window around the current hour in Tokyo, which is never UTC's
+	tokyo := time.Now().In(time.FixedZone("JST", 9*60*60))
+	quiet := &QuietHours{Start: tokyo.Add(-time.Hour).Format("15:04"), End: tokyo.Add(time.Hour).Format("15:04")}
+
+	userID := "42"
+	for tz, deferred := range map[string]bool{"Asia/Tokyo": true, "UTC": false} {
+		n := inAppNotification(1)
+		n.Status = StatusSending
+		n.Recipient.UserID = &userID
+		repo := newFakeRepo(n)
+		repo.quietHours = quiet
+		s := newTestService(repo)
+		s.preferences = userDirectory{timezone: tz}
+
+		require.Equal(t, deferred, s.deferForQuietHours(context.Background(), n), tz)
+	}
+
+	// a raw address has no user timezone and the window is read in UTC
+	n := inAppNotification(1)
+	n.Status = StatusSending
+	repo := newFakeRepo(n)
+	repo.quietHours = quiet
+	s := newTestService(repo)
+	s.preferences = userDirectory{timezone: "Asia/Tokyo"}
+	require.False(t, s.deferForQuietHours(context.Background(), n))
+}
@@ -0,0 +1,214 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/robfig/cron/v3"
+)
+
+// Standard five field cron expressions plus descriptors such as @daily or @weekly.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// nextOccurrence returns the first run of expr, evaluated in timezone, strictly after `after`.
+func nextOccurrence(expr, timezone string, after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, shared.ErrInvalidTimezone
+	}
+
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return time.Time{}, shared.ErrInvalidCronExpression
+	}
+
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, shared.ErrNoUpcomingOccurrence
+	}
+	return next.UTC(), nil
+}
+
+// firstRun returns the first occurrence at or after start_at (or now), or nil when
+// the schedule has no occurrence before end_at.
+func firstRun(rs *RecurringSchedule, now time.Time) (*time.Time, error) {
+	after := now
+	if rs.StartAt != nil && rs.StartAt.After(now) {
+		// Next is exclusive, step back so start_at itself can match
+		after = rs.StartAt.Add(-time.Second)
+	}
+
+	next, err := nextOccurrence(rs.CronExpr, rs.Timezone, after)
+	if err != nil {
+		return nil, err
+	}
+	if rs.EndAt != nil && next.After(*rs.EndAt) {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// CreateRecurring validates a schedule as every run will be sent, so a run
+// cannot fail for a reason a single send would have rejected up front.
+func (s *serviceImpl) CreateRecurring(ctx context.Context, rs *RecurringSchedule) (int64, error) {
+	if rs.Timezone == "" {
+		rs.Timezone = "UTC"
+	}
+
+	if err := s.validateRecurring(ctx, rs); err != nil {
+		return -1, err
+	}
+
+	next, err := firstRun(rs, time.Now())
+	if err != nil {
+		return -1, err
+	}
+	if next == nil {
+		return -1, shared.ErrNoUpcomingOccurrence
+	}
+
+	rs.Status = RecurringActive
+	rs.NextRunAt = next
+
+	return s.repo.CreateRecurring(ctx, rs)
+}
+
+func (s *serviceImpl) validateRecurring(ctx context.Context, rs *RecurringSchedule) error {
+	tpl, err := s.templateRepo.GetByID(ctx, rs.TemplateID)
+	if errors.Is(err, shared.ErrRecordNotFound) || (err == nil && tpl == nil) {
+		return shared.ErrTemplateNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if tpl.Channel != rs.Channel {
+		return shared.ErrTemplateChannelMismatch
+	}
+
+	return s.prepare(ctx, &Notification{
+		Channel:          rs.Channel,
+		TemplateID:       rs.TemplateID,
+		Recipient:        rs.Recipient,
+		TemplateKeyValue: rs.TemplateKeyValue,
+	})
+}
+
+func (s *serviceImpl) GetRecurring(ctx context.Context, id int64) (*RecurringSchedule, error) {
+	return s.repo.GetRecurring(ctx, id)
+}
+
+func (s *serviceImpl) ListRecurring(ctx context.Context) ([]*RecurringSchedule, error) {
+	return s.repo.ListRecurring(ctx)
+}
+
+func (s *serviceImpl) PauseRecurring(ctx context.Context, id int64) error {
+	rs, err := s.repo.GetRecurring(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	paused, err := s.repo.UpdateRecurringStatus(ctx, id, RecurringActive, RecurringPaused, rs.NextRunAt)
+	if err != nil {
+		return err
+	}
+	if !paused {
+		return shared.ErrRecurringStateConflict
+	}
+	return nil
+}
+
+// ResumeRecurring reactivates a paused schedule from the next occurrence after now;
+// runs missed while paused are skipped.
+func (s *serviceImpl) ResumeRecurring(ctx context.Context, id int64) error {
+	rs, err := s.repo.GetRecurring(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	next, err := firstRun(rs, time.Now())
+	if err != nil {
+		return err
+	}
+
+	to := RecurringActive
+	if next == nil {
+		to = RecurringCompleted
+	}
+
+	resumed, err := s.repo.UpdateRecurringStatus(ctx, id, RecurringPaused, to, next)
+	if err != nil {
+		return err
+	}
+	if !resumed {
+		return shared.ErrRecurringStateConflict
+	}
+	return nil
+}
+
+func (s *serviceImpl) DeleteRecurring(ctx context.Context, id int64) error {
+	deleted, err := s.repo.DeleteRecurring(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return shared.ErrRecordNotFound
+	}
+	return nil
+}
+
+// materializeRecurring turns every due recurring schedule into a scheduled
+// notification for its current run and advances the schedule to its next run.
+// If the scheduler was down, missed runs collapse into a single notification.
+func (s *Scheduler) materializeRecurring(ctx context.Context) {
+	due, err := s.repo.FindDueRecurring(ctx, s.batch)
+	if err != nil {
+		s.log.Error(ctx, "failed to fetch due recurring schedules", logger.Error(err))
+		return
+	}
+
+	now := time.Now()
+	for _, rs := range due {
+		runAt := *rs.NextRunAt
+
+		var next *time.Time
+		nextRun, err := nextOccurrence(rs.CronExpr, rs.Timezone, now)
+		if err != nil {
+			s.log.Error(ctx, "failed to compute next recurring run",
+				logger.Int64("recurring_schedule_id", rs.ID),
+				logger.Error(err),
+			)
+			continue
+		}
+		if rs.EndAt == nil || !nextRun.After(*rs.EndAt) {
+			next = &nextRun
+		}
+
+		n := &Notification{
+			Channel:          rs.Channel,
+			TemplateID:       rs.TemplateID,
+			Recipient:        rs.Recipient,
+			TemplateKeyValue: rs.TemplateKeyValue,
+			Status:           StatusScheduled,
+			Priority:         PriorityNormal,
+			ScheduledAt:      &runAt,
+		}
+
+		created, err := s.repo.MaterializeRecurring(ctx, rs, n, next)
+		if err != nil {
+			s.log.Error(ctx, "failed to materialize recurring schedule",
+				logger.Int64("recurring_schedule_id", rs.ID),
+				logger.Error(err),
+			)
+			continue
+		}
+		if created {
+			s.log.Info(ctx, "materialized recurring notification",
+				logger.Int64("recurring_schedule_id", rs.ID),
+				logger.Int64("notification_id", n.ID),
+			)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) CreateRecurring(w http.ResponseWriter, r *http.Request) {
+	var req RecurringScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// a run is materialized with the channel, template, recipient and data only
+	if len(req.Attachments) > 0 || len(req.Fallback) > 0 || (req.Priority != "" && req.Priority != PriorityNormal) {
+		http.Error(w, shared.ErrRecurringOptionUnsupported.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := mapRequestToNotification(r.Context(), req.SendNowRequest, h.users)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rs := &RecurringSchedule{
+		CronExpr:         req.Cron,
+		Timezone:         req.Timezone,
+		Channel:          n.Channel,
+		TemplateID:       n.TemplateID,
+		Recipient:        n.Recipient,
+		TemplateKeyValue: n.TemplateKeyValue,
+		StartAt:          req.StartAt,
+		EndAt:            req.EndAt,
+	}
+
+	if _, err := h.service.CreateRecurring(r.Context(), rs); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusCreated, rs)
+}
+
+func (h *Handler) GetRecurring(w http.ResponseWriter, r *http.Request) {
+	id, ok := recurringIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	rs, err := h.service.GetRecurring(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, rs)
+}
+
+func (h *Handler) ListRecurring(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.service.ListRecurring(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, schedules)
+}
+
+func (h *Handler) PauseRecurring(w http.ResponseWriter, r *http.Request) {
+	id, ok := recurringIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.PauseRecurring(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *Handler) ResumeRecurring(w http.ResponseWriter, r *http.Request) {
+	id, ok := recurringIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.ResumeRecurring(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *Handler) DeleteRecurring(w http.ResponseWriter, r *http.Request) {
+	id, ok := recurringIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRecurring(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func recurringIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid recurring schedule ID ", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextOccurrenceInTimezone(t *testing.T) {
+	after := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) // Monday
+
+	// Every Monday 09:00 in Berlin (UTC+1 in March before DST)
+	next, err := nextOccurrence("0 9 * * MON", "Europe/Berlin", after)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), next)
+
+	next, err = nextOccurrence("@daily", "UTC", after)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), next)
+}
+
+func TestNextOccurrenceInvalid(t *testing.T) {
+	_, err := nextOccurrence("not a cron", "UTC", time.Now())
+	require.ErrorIs(t, err, shared.ErrInvalidCronExpression)
+
+	_, err = nextOccurrence("0 9 * * *", "Mars/Olympus", time.Now())
+	require.ErrorIs(t, err, shared.ErrInvalidTimezone)
+}
+
+func TestFirstRunRespectsWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	rs := &RecurringSchedule{CronExpr: "0 9 * * *", Timezone: "UTC", StartAt: &start}
+	next, err := firstRun(rs, now)
+	require.NoError(t, err)
+	require.Equal(t, start, *next)
+
+	end := time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC)
+	rs = &RecurringSchedule{CronExpr: "0 9 * * *", Timezone: "UTC", EndAt: &end}
+	next, err = firstRun(rs, now)
+	require.NoError(t, err)
+	require.Nil(t, next)
+}
+
+func (r *fakeRepo) CreateRecurring(_ context.Context, rs *RecurringSchedule) (int64, error) {
+	rs.ID = 1
+	return rs.ID, nil
+}
+
+func TestCreateRecurringHandlerValidates(t *testing.T) {
+	svc := newTestService(newFakeRepo())
+	svc.senders[shared.ChannelInApp] = &allowSender{allowed: "42"}
+	srv := httptest.NewServer(NewRecurringRoutes(svc, nil))
+	defer srv.Close()
+
+	for body, status := range map[string]int{
+		`{"channel":"in_app","template_id":7,"recipient":{"user":"42"},"cron":"@daily"}`: http.StatusCreated,
+		// the template is an in_app one
+		`{"channel":"email","template_id":7,"recipient":{"email":"ada@example.com"},"cron":"@daily"}`: http.StatusBadRequest,
+		// the sender check of a single send
+		`{"channel":"in_app","template_id":7,"recipient":{"user":"43"},"cron":"@daily"}`: http.StatusBadRequest,
+		// not stored on the schedule
+		`{"channel":"in_app","template_id":7,"recipient":{"user":"42"},"cron":"@daily","priority":"urgent"}`:                                                http.StatusBadRequest,
+		`{"channel":"in_app","template_id":7,"recipient":{"user":"42"},"cron":"@daily","fallback":[{"channel":"email","recipient":{"email":"a@b.co"}}]}`:    http.StatusBadRequest,
+		`{"channel":"email","template_id":7,"recipient":{"email":"ada@example.com"},"cron":"@daily","attachments":[{"filename":"a.txt","content":"YQ=="}]}`: http.StatusBadRequest,
+	} {
+		resp, err := http.Post(srv.URL+"/", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// maxThreadDepth bounds how far thread_of is followed to the thread root.
+const maxThreadDepth = 10
+
+// applySlackOptions stores the thread or update reference of a send request
+// on the recipient.
+func applySlackOptions(channel shared.Channel, opts *SlackOptions, r *NotificationRecipient) error {
+	if opts == nil {
+		return nil
+	}
+	if channel != shared.ChannelSlack {
+		return errors.New("slack options are only supported for the slack channel")
+	}
+	if opts.ThreadOf != nil && opts.UpdateOf != nil {
+		return errors.New("provide either thread_of or update_of, not both")
+	}
+
+	r.ThreadOf, r.UpdateOf = opts.ThreadOf, opts.UpdateOf
+	return nil
+}
+
+// reference returns the referenced notification ID and how it is used.
+func (r NotificationRecipient) reference() (*int64, ReferenceMode) {
+	if r.UpdateOf != nil {
+		return r.UpdateOf, ReferenceUpdate
+	}
+	return r.ThreadOf, ReferenceThread
+}
+
+// validateReference rejects a send referencing a notification that does not
+// exist or is not a Slack one. The referenced one need not be sent yet.
+func (s *serviceImpl) validateReference(ctx context.Context, n *Notification) error {
+	id, _ := n.Recipient.reference()
+	if id == nil {
+		return nil
+	}
+
+	ref, err := s.repo.GetByID(ctx, *id)
+	if errors.Is(err, shared.ErrRecordNotFound) {
+		return shared.ErrInvalidReference
+	}
+	if err != nil {
+		return err
+	}
+	if ref.Channel != shared.ChannelSlack {
+		return shared.ErrInvalidReference
+	}
+	return nil
+}
+
+// resolveReference sets n.Reference to the message of the referenced
+// notification. While that one is still on its way the error is retryable;
+// once it has failed for good, so has this send. Thread replies go to the
+// root of the thread, as Slack does not nest threads.
+func (s *serviceImpl) resolveReference(ctx context.Context, n *Notification) error {
+	id, mode := n.Recipient.reference()
+	if id == nil {
+		return nil
+	}
+
+	ref, err := s.repo.GetByID(ctx, *id)
+	for depth := 0; err == nil && mode == ReferenceThread && ref.Recipient.ThreadOf != nil; depth++ {
+		if depth == maxThreadDepth {
+			return permanent(fmt.Errorf("thread of notification %d is nested too deep", *id))
+		}
+		ref, err = s.repo.GetByID(ctx, *ref.Recipient.ThreadOf)
+	}
+	if errors.Is(err, shared.ErrRecordNotFound) {
+		return permanent(shared.ErrInvalidReference)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch ref.Status {
+	case StatusSent:
+	case StatusFailed, StatusCancelled, StatusSuppressed:
+		return permanent(fmt.Errorf("referenced notification %d was not delivered", ref.ID))
+	default:
+		return fmt.Errorf("referenced notification %d is not sent yet", ref.ID)
+	}
+
+	if ref.ProviderMessageID == "" {
+		return permanent(fmt.Errorf("referenced notification %d has no slack message to %s", ref.ID, mode))
+	}
+
+	n.Reference = &MessageReference{
+		Mode:      mode,
+		Channel:   ref.ProviderChannel,
+		MessageID: ref.ProviderMessageID,
+	}
+	return nil
+}
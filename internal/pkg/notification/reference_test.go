@@ -0,0 +1,26 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySlackOptions(t *testing.T) {
+	id := int64(42)
+
+	var r NotificationRecipient
+	require.NoError(t, applySlackOptions(shared.ChannelSlack, &SlackOptions{UpdateOf: &id}, &r))
+	ref, mode := r.reference()
+	require.Equal(t, id, *ref)
+	require.Equal(t, ReferenceUpdate, mode)
+
+	r = NotificationRecipient{}
+	require.NoError(t, applySlackOptions(shared.ChannelSlack, &SlackOptions{ThreadOf: &id}, &r))
+	_, mode = r.reference()
+	require.Equal(t, ReferenceThread, mode)
+
+	require.Error(t, applySlackOptions(shared.ChannelEmail, &SlackOptions{ThreadOf: &id}, &r))
+	require.Error(t, applySlackOptions(shared.ChannelSlack, &SlackOptions{ThreadOf: &id, UpdateOf: &id}, &r))
+}
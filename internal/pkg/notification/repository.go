@@ -3,15 +3,55 @@ package notification
 import (
 	"context"
 	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
 type Repository interface {
 	Create(ctx context.Context, n *Notification) (int64, error)
+	CreateWithOutbox(ctx context.Context, n *Notification, topic string) (int64, error)
+	ClaimPendingOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, cause string, retryAt time.Time, dead bool) error
 	UpdateStatus(ctx context.Context, id int64, status NotificationStatus) error
 	GetByID(ctx context.Context, id int64) (*Notification, error)
 	List(ctx context.Context, filter NotificationFilter) ([]*Notification, error)
-	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkSent(ctx context.Context, id int64, sentAt time.Time, result SendResult) (bool, error)
+	FailWithFallback(ctx context.Context, id int64, next *Notification, topic string) error
+	ScheduleRetry(ctx context.Context, id int64, nextAttemptAt time.Time) (bool, error)
 	AcquireForSending(ctx context.Context, id int64) (bool, error)
+	ClaimForDispatch(ctx context.Context, id int64, after StuckAfter) (bool, error)
+	ReleaseDispatch(ctx context.Context, id int64) error
+	Cancel(ctx context.Context, id int64) (bool, error)
+	Reschedule(ctx context.Context, id int64, when time.Time) (bool, error)
 	FindDue(ctx context.Context, limit int) ([]NotificationScheduled, error)
-	FindStuckSending(ctx context.Context, olderThan time.Duration, limit int) ([]NotificationScheduled, error)
+	CreateAttempt(ctx context.Context, a *NotificationAttempt) (int64, error)
+	ListAttempts(ctx context.Context, notificationID int64) ([]*NotificationAttempt, error)
+	ReserveIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error)
+	GetIdempotencyKey(ctx context.Context, clientID, key string) (*IdempotencyKey, error)
+	ReclaimIdempotencyKey(ctx context.Context, key *IdempotencyKey, now time.Time) (bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key *IdempotencyKey, resp NotificationResponse, expiresAt time.Time) (bool, error)
+	DeleteIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error)
+	CreateRecurring(ctx context.Context, rs *RecurringSchedule) (int64, error)
+	GetRecurring(ctx context.Context, id int64) (*RecurringSchedule, error)
+	ListRecurring(ctx context.Context) ([]*RecurringSchedule, error)
+	UpdateRecurringStatus(ctx context.Context, id int64, from, to RecurringStatus, nextRunAt *time.Time) (bool, error)
+	DeleteRecurring(ctx context.Context, id int64) (bool, error)
+	FindDueRecurring(ctx context.Context, limit int) ([]*RecurringSchedule, error)
+	MaterializeRecurring(ctx context.Context, rs *RecurringSchedule, n *Notification, nextRunAt *time.Time) (bool, error)
+	CreateMessage(ctx context.Context, m *Message, ns []*Notification, topics map[shared.Channel]string) error
+	GetMessage(ctx context.Context, id int64) (*Message, error)
+	CreateBatch(ctx context.Context, b *NotificationBatch, ns []*Notification, topic string, chunkSize int) error
+	GetBatch(ctx context.Context, id int64) (*NotificationBatch, error)
+	CountBatchStatuses(ctx context.Context, batchID int64) (map[NotificationStatus]int, error)
+	UpsertQuietHours(ctx context.Context, q *QuietHours) error
+	GetQuietHours(ctx context.Context, channel shared.Channel, recipient string) (*QuietHours, error)
+	DeleteQuietHours(ctx context.Context, channel shared.Channel, recipient string) (bool, error)
+	DeferSending(ctx context.Context, id int64, until time.Time, reason string) (bool, error)
+	HoldForDigest(ctx context.Context, id int64, until time.Time, reason, key string) (bool, error)
+	ClaimDigest(ctx context.Context, key string, deliveredBy int64) ([]int64, error)
+	MarkSuppressed(ctx context.Context, id int64, reason string) error
+	ResetFailed(ctx context.Context, ids []int64) (int, error)
+	ListAttachments(ctx context.Context, notificationID int64) ([]Attachment, error)
+	FindStuck(ctx context.Context, after StuckAfter, limit int) ([]NotificationScheduled, error)
 }
@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+)
+
+// permanentError marks a delivery failure that retrying cannot fix,
+// e.g. a missing template or a render error.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent tells the Kafka consumer to dead-letter the message instead of
+// processing it again.
+func (e permanentError) Permanent() bool { return true }
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Permanent lets a Sender report a failure that retrying cannot fix, such as
+// an unknown recipient, so the notification fails without further attempts.
+func Permanent(err error) error {
+	return permanent(err)
+}
+
+// RetryAfterError is returned by a Sender when the provider asked to wait,
+// e.g. because of rate limiting. The next attempt is not made before After,
+// even if the backoff would be shorter.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e RetryAfterError) Error() string { return e.Err.Error() }
+
+func (e RetryAfterError) Unwrap() error { return e.Err }
+
+// nextRetryDelay is the backoff for the attempt, extended to what the
+// provider asked for through a RetryAfterError.
+func nextRetryDelay(policy config.RetryPolicy, attempts int, cause error) time.Duration {
+	delay := retryDelay(policy, attempts)
+
+	var ra RetryAfterError
+	if errors.As(cause, &ra) && ra.After > delay {
+		delay = ra.After
+	}
+	return delay
+}
+
+// retryDelay returns the backoff before the next attempt, doubling the base
+// delay for every attempt already made, capped at MaxDelay and spread by Jitter.
+func retryDelay(policy config.RetryPolicy, attempts int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempts; i++ {
+		if delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+		if policy.MaxDelay > 0 && delay >= policy.MaxDelay {
+			break
+		}
+	}
+
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		spread := float64(delay) * policy.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
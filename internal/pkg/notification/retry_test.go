@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryDelayBackoff(t *testing.T) {
+	policy := config.RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    10 * time.Second,
+	}
+
+	require.Equal(t, time.Second, retryDelay(policy, 1))
+	require.Equal(t, 2*time.Second, retryDelay(policy, 2))
+	require.Equal(t, 4*time.Second, retryDelay(policy, 3))
+	require.Equal(t, 8*time.Second, retryDelay(policy, 4))
+	require.Equal(t, 10*time.Second, retryDelay(policy, 5))
+	require.Equal(t, 10*time.Second, retryDelay(policy, 100))
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	policy := config.RetryPolicy{
+		BaseDelay: 10 * time.Second,
+		Jitter:    0.2,
+	}
+
+	for range 100 {
+		d := retryDelay(policy, 1)
+		require.GreaterOrEqual(t, d, 8*time.Second)
+		require.LessOrEqual(t, d, 12*time.Second)
+	}
+}
+
+func TestRetryConfigForChannel(t *testing.T) {
+	cfg := config.RetryConfig{
+		Default: config.RetryPolicy{MaxAttempts: 5, BaseDelay: 30 * time.Second, Jitter: 0.2},
+		Channels: map[string]config.RetryPolicy{
+			"slack": {MaxAttempts: 3},
+		},
+	}
+
+	slack := cfg.ForChannel("slack")
+	require.Equal(t, 3, slack.MaxAttempts)
+	require.Equal(t, 30*time.Second, slack.BaseDelay)
+	require.Equal(t, 0.2, slack.Jitter)
+
+	require.Equal(t, cfg.Default, cfg.ForChannel("email"))
+}
+
+func TestRetryConfigForChannelZeroInherits(t *testing.T) {
+	cfg := config.RetryConfig{
+		Default:  config.RetryPolicy{MaxAttempts: 5, BaseDelay: 30 * time.Second},
+		Channels: map[string]config.RetryPolicy{"slack": {MaxAttempts: 0, BaseDelay: time.Second}},
+	}
+	require.Equal(t, 5, cfg.ForChannel("slack").MaxAttempts)
+
+	// without any max_attempts a failure is still retried
+	require.Equal(t, 5, config.RetryConfig{}.ForChannel("email").MaxAttempts)
+}
+
+func TestPermanentErrorUnwrap(t *testing.T) {
+	cause := errors.New("template missing")
+	err := permanent(cause)
+
+	require.ErrorIs(t, err, cause)
+	require.Equal(t, cause.Error(), err.Error())
+}
+
+func TestNextRetryDelayHonorsRetryAfter(t *testing.T) {
+	policy := config.RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	limited := RetryAfterError{Err: errors.New("rate limited"), After: 30 * time.Second}
+
+	require.Equal(t, time.Second, nextRetryDelay(policy, 1, errors.New("boom")))
+	require.Equal(t, 30*time.Second, nextRetryDelay(policy, 1, limited))
+	require.Equal(t, 4*time.Second, nextRetryDelay(policy, 3, RetryAfterError{Err: errors.New("rate limited"), After: time.Second}))
+}
@@ -11,14 +11,63 @@ func (h *Handler) Routes() http.Handler {
 
 	r.Post("/", h.SendNow)
 	r.Post("/schedule", h.Schedule)
+	r.Post("/batch", h.SendBatch)
+	r.Get("/batches/{id}", h.GetBatch)
 	r.Get("/{id}/status", h.GetByID)
+	r.Get("/{id}/attempts", h.ListAttempts)
+	r.Delete("/{id}", h.Cancel)
+	r.Patch("/{id}/schedule", h.Reschedule)
 	r.Get("/{id}/initiate", h.Process)
 	r.Get("/", h.List)
 
 	return r
 }
 
-func NewNotificationRoutes(service Service) http.Handler {
-	handler := NewHandler(service)
+func NewNotificationRoutes(service Service, users UserDirectory) http.Handler {
+	handler := NewHandler(service, users)
 	return handler.Routes()
 }
+
+func (h *Handler) RecurringRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.CreateRecurring)
+	r.Get("/", h.ListRecurring)
+	r.Get("/{id}", h.GetRecurring)
+	r.Post("/{id}/pause", h.PauseRecurring)
+	r.Post("/{id}/resume", h.ResumeRecurring)
+	r.Delete("/{id}", h.DeleteRecurring)
+
+	return r
+}
+
+func NewRecurringRoutes(service Service, users UserDirectory) http.Handler {
+	return NewHandler(service, users).RecurringRoutes()
+}
+
+func (h *Handler) QuietHoursRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Put("/{channel}/{recipient}", h.SetQuietHours)
+	r.Get("/{channel}/{recipient}", h.GetQuietHours)
+	r.Delete("/{channel}/{recipient}", h.DeleteQuietHours)
+
+	return r
+}
+
+func NewQuietHoursRoutes(service Service) http.Handler {
+	return NewHandler(service, nil).QuietHoursRoutes()
+}
+
+func (h *Handler) MessageRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.SendMessage)
+	r.Get("/{id}", h.GetMessage)
+
+	return r
+}
+
+func NewMessageRoutes(service Service, users UserDirectory) http.Handler {
+	return NewHandler(service, users).MessageRoutes()
+}
@@ -7,21 +7,40 @@ import (
 	"time"
 
 	"github.com/ckshitij/notify-srv/internal/config"
-	"github.com/ckshitij/notify-srv/internal/kafka"
 	"github.com/ckshitij/notify-srv/internal/logger"
 )
 
+// StuckAfter is how long a notification may stay dispatched or sending before
+// the scheduler assumes its instance died and dispatches it again.
+type StuckAfter struct {
+	Dispatched time.Duration
+	// Sending only applies while the current attempt has no recorded success.
+	Sending time.Duration
+}
+
+// stuckAfter lets a sending notification go only once its delivery, bounded
+// by deliveryTimeout, must be over; the margin covers the checks around it.
+var stuckAfter = StuckAfter{
+	Dispatched: 10 * time.Minute,
+	Sending:    deliveryTimeout + 3*time.Minute,
+}
+
+// MessagePublisher publishes a single Kafka message.
+type MessagePublisher interface {
+	SendMessage(topic string, key string, message []byte) (int32, int64, error)
+}
+
 type Scheduler struct {
 	repo     Repository
 	log      logger.Logger
 	interval time.Duration
 	batch    int
 	workers  int
-	producer *kafka.Producer
+	producer MessagePublisher
 	kafkaCfg *config.KafkaConfig
 }
 
-func NewSchedular(repo Repository, log logger.Logger, interval time.Duration, batch int, workers int, producer *kafka.Producer, kafkaCfg *config.KafkaConfig) *Scheduler {
+func NewSchedular(repo Repository, log logger.Logger, interval time.Duration, batch int, workers int, producer MessagePublisher, kafkaCfg *config.KafkaConfig) *Scheduler {
 	return &Scheduler{repo, log, interval, batch, workers, producer, kafkaCfg}
 }
 
@@ -50,7 +69,7 @@ func (s *Scheduler) fetchCandidates(ctx context.Context) ([]NotificationSchedule
 		return nil, err
 	}
 
-	stuck, err := s.repo.FindStuckSending(ctx, 10*time.Minute, s.batch)
+	stuck, err := s.repo.FindStuck(ctx, stuckAfter, s.batch)
 	if err != nil {
 		s.log.Error(ctx, "failed to fetch stuck notifications", logger.Error(err))
 		return nil, err
@@ -60,6 +79,8 @@ func (s *Scheduler) fetchCandidates(ctx context.Context) ([]NotificationSchedule
 }
 
 func (s *Scheduler) tick(ctx context.Context) {
+	s.materializeRecurring(ctx)
+
 	notifications, err := s.fetchCandidates(ctx)
 	if err != nil {
 		return
@@ -87,6 +108,13 @@ func (s *Scheduler) tick(ctx context.Context) {
 					return
 				}
 
+				// Claim before publishing so a concurrent cancel or reschedule
+				// either wins or sees the notification as no longer scheduled
+				claimed, err := s.repo.ClaimForDispatch(ctx, n.ID, stuckAfter)
+				if err != nil || !claimed {
+					return
+				}
+
 				key := strconv.FormatInt(n.ID, 10)
 				msg, _ := json.Marshal(n.ID)
 
@@ -97,19 +125,15 @@ func (s *Scheduler) tick(ctx context.Context) {
 						logger.Int64("notification_id", n.ID),
 						logger.Error(err),
 					)
+					s.repo.ReleaseDispatch(ctx, n.ID)
 					return
 				}
-
-				// Mark as dispatched so scheduler won't pick it again
-				if err := s.repo.UpdateStatus(ctx, n.ID, StatusDispatched); err != nil {
-					s.log.Error(
-						ctx,
-						"failed to mark notification dispatched",
-						logger.Int64("notification_id", n.ID),
-						logger.Error(err),
-					)
-				}
 			}(n)
 		}
 	}
+
+	// Wait for the dispatches so the next tick does not overlap with them
+	for range s.workers {
+		workerPool <- struct{}{}
+	}
 }
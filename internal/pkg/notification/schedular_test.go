@@ -0,0 +1,207 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type scheduledRow struct {
+	status      NotificationStatus
+	scheduledAt time.Time
+	updatedAt   time.Time
+	// the current attempt was recorded as delivered
+	delivered bool
+}
+
+func (r *scheduledRow) stuck(after StuckAfter) bool {
+	switch r.status {
+	case StatusDispatched:
+		return time.Since(r.updatedAt) > after.Dispatched
+	case StatusSending:
+		return time.Since(r.updatedAt) > after.Sending && !r.delivered
+	}
+	return false
+}
+
+// fakeSchedule applies the conditional updates of the store to rows in memory.
+type fakeSchedule struct {
+	Repository
+	mu   sync.Mutex
+	rows map[int64]*scheduledRow
+}
+
+func (f *fakeSchedule) FindDueRecurring(context.Context, int) ([]*RecurringSchedule, error) {
+	return nil, nil
+}
+
+func (f *fakeSchedule) FindDue(context.Context, int) ([]NotificationScheduled, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []NotificationScheduled
+	for id, r := range f.rows {
+		if r.status == StatusScheduled && !r.scheduledAt.After(time.Now()) {
+			out = append(out, NotificationScheduled{ID: id, Channel: shared.ChannelEmail})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSchedule) FindStuck(_ context.Context, after StuckAfter, _ int) ([]NotificationScheduled, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []NotificationScheduled
+	for id, r := range f.rows {
+		if r.stuck(after) {
+			out = append(out, NotificationScheduled{ID: id, Channel: shared.ChannelEmail})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSchedule) ClaimForDispatch(_ context.Context, id int64, after StuckAfter) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.rows[id]
+	due := r.status == StatusScheduled && !r.scheduledAt.After(time.Now())
+	if !due && !r.stuck(after) {
+		return false, nil
+	}
+	r.status, r.updatedAt = StatusDispatched, time.Now()
+	return true, nil
+}
+
+func (f *fakeSchedule) ReleaseDispatch(_ context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r := f.rows[id]; r.status == StatusDispatched {
+		r.status = StatusScheduled
+	}
+	return nil
+}
+
+func (f *fakeSchedule) Cancel(_ context.Context, id int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r := f.rows[id]; r.status == StatusScheduled {
+		r.status = StatusCancelled
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeSchedule) Reschedule(_ context.Context, id int64, when time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r := f.rows[id]; r.status == StatusScheduled {
+		r.scheduledAt = when
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeSchedule) GetByID(_ context.Context, id int64) (*Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.rows[id]
+	if !ok {
+		return nil, shared.ErrRecordNotFound
+	}
+	return &Notification{ID: id, Status: r.status}, nil
+}
+
+type fakeProducer struct {
+	mu   sync.Mutex
+	err  error
+	sent []string
+}
+
+func (p *fakeProducer) SendMessage(_ string, key string, _ []byte) (int32, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return 0, 0, p.err
+	}
+	p.sent = append(p.sent, key)
+	return 0, 0, nil
+}
+
+func newScheduleTest(rows map[int64]*scheduledRow) (*fakeSchedule, *fakeProducer, *Scheduler, *serviceImpl) {
+	repo := &fakeSchedule{rows: rows}
+	producer := &fakeProducer{}
+	kafkaCfg := &config.KafkaConfig{Topics: map[string]string{"email": "notifications-email"}}
+	scheduler := NewSchedular(repo, nopLogger{}, time.Second, 10, 2, producer, kafkaCfg)
+	return repo, producer, scheduler, newTestService(repo)
+}
+
+func TestCancelBeforeDispatch(t *testing.T) {
+	repo, producer, scheduler, s := newScheduleTest(map[int64]*scheduledRow{
+		1: {status: StatusScheduled, scheduledAt: time.Now().Add(-time.Second)},
+	})
+
+	require.NoError(t, s.Cancel(context.Background(), 1))
+	scheduler.tick(context.Background())
+
+	require.Empty(t, producer.sent)
+	require.Equal(t, StatusCancelled, repo.rows[1].status)
+}
+
+func TestCancelAndRescheduleAfterDispatch(t *testing.T) {
+	repo, producer, scheduler, s := newScheduleTest(map[int64]*scheduledRow{
+		1: {status: StatusScheduled, scheduledAt: time.Now().Add(-time.Second)},
+	})
+
+	scheduler.tick(context.Background())
+	require.Equal(t, []string{"1"}, producer.sent)
+
+	require.ErrorIs(t, s.Cancel(context.Background(), 1), shared.ErrNotificationNotScheduled)
+	require.ErrorIs(t, s.Reschedule(context.Background(), 1, time.Now().Add(time.Hour)), shared.ErrNotificationNotScheduled)
+	require.Equal(t, StatusDispatched, repo.rows[1].status)
+}
+
+func TestRescheduleBeforeDispatch(t *testing.T) {
+	_, producer, scheduler, s := newScheduleTest(map[int64]*scheduledRow{
+		1: {status: StatusScheduled, scheduledAt: time.Now().Add(-time.Second)},
+	})
+
+	require.NoError(t, s.Reschedule(context.Background(), 1, time.Now().Add(time.Hour)))
+	scheduler.tick(context.Background())
+	require.Empty(t, producer.sent)
+}
+
+func TestFailedPublishReleasesDispatch(t *testing.T) {
+	repo, producer, scheduler, s := newScheduleTest(map[int64]*scheduledRow{
+		1: {status: StatusScheduled, scheduledAt: time.Now().Add(-time.Second)},
+	})
+	producer.err = errors.New("broker unavailable")
+
+	scheduler.tick(context.Background())
+	require.Equal(t, StatusScheduled, repo.rows[1].status)
+	require.NoError(t, s.Cancel(context.Background(), 1))
+}
+
+func TestDispatchRecoversStuckNotifications(t *testing.T) {
+	stale := time.Now().Add(-stuckAfter.Dispatched - time.Minute)
+	_, producer, scheduler, _ := newScheduleTest(map[int64]*scheduledRow{
+		1: {status: StatusDispatched, updatedAt: stale},
+		2: {status: StatusSending, updatedAt: stale},
+		3: {status: StatusDispatched, updatedAt: time.Now()},
+		// still within its delivery
+		4: {status: StatusSending, updatedAt: time.Now().Add(-deliveryTimeout)},
+		// delivered, only the status update was lost
+		5: {status: StatusSending, updatedAt: stale, delivered: true},
+	})
+
+	scheduler.tick(context.Background())
+	require.ElementsMatch(t, []string{"1", "2"}, producer.sent)
+
+	// the claim restarted their clocks
+	scheduler.tick(context.Background())
+	require.Len(t, producer.sent, 2)
+}
@@ -6,6 +6,22 @@ import (
 	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
 )
 
+// SendResult carries what the provider reported back for a delivery attempt.
+type SendResult struct {
+	ProviderResponse string
+	// ProviderChannel and ProviderMessageID identify the delivered message
+	// for later replies or updates, when the provider has such IDs.
+	ProviderChannel   string
+	ProviderMessageID string
+}
+
 type Sender interface {
-	Send(ctx context.Context, n Notification, content renderer.RenderedTemplate) error
+	Send(ctx context.Context, n Notification, content renderer.RenderedTemplate) (SendResult, error)
+}
+
+// SendValidator is implemented by senders that can reject a notification up
+// front, such as one using a sender identity that is not configured, rather
+// than failing it at delivery time.
+type SendValidator interface {
+	Validate(n Notification) error
 }
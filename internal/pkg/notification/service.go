@@ -3,12 +3,34 @@ package notification
 import (
 	"context"
 	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
 type Service interface {
 	SendNow(ctx context.Context, n *Notification) (int64, error)
 	Schedule(ctx context.Context, n *Notification, when time.Time) (int64, error)
+	SendMessage(ctx context.Context, m *Message, ns []*Notification) error
+	GetMessage(ctx context.Context, id int64) (*Message, error)
+	CheckBatchSize(total int) error
+	SendBatch(ctx context.Context, b *NotificationBatch, ns []*Notification) (map[int]error, error)
+	GetBatch(ctx context.Context, id int64) (*NotificationBatch, error)
+	Cancel(ctx context.Context, notificationID int64) error
+	Reschedule(ctx context.Context, notificationID int64, when time.Time) error
 	Process(ctx context.Context, notificationID int64) error
 	GetByID(ctx context.Context, notificationID int64) (*Notification, error)
 	List(ctx context.Context, filter NotificationFilter) ([]*Notification, error)
+	ListAttempts(ctx context.Context, notificationID int64) ([]*NotificationAttempt, error)
+	BeginIdempotent(ctx context.Context, key *IdempotencyKey) (*NotificationResponse, error)
+	FinishIdempotent(ctx context.Context, key *IdempotencyKey, resp *NotificationResponse, cause error)
+	CreateRecurring(ctx context.Context, rs *RecurringSchedule) (int64, error)
+	GetRecurring(ctx context.Context, id int64) (*RecurringSchedule, error)
+	ListRecurring(ctx context.Context) ([]*RecurringSchedule, error)
+	PauseRecurring(ctx context.Context, id int64) error
+	ResumeRecurring(ctx context.Context, id int64) error
+	DeleteRecurring(ctx context.Context, id int64) error
+	SetQuietHours(ctx context.Context, q *QuietHours) error
+	GetQuietHours(ctx context.Context, channel shared.Channel, recipient string) (*QuietHours, error)
+	DeleteQuietHours(ctx context.Context, channel shared.Channel, recipient string) error
+	ReplayDeadLetters(ctx context.Context, channel shared.Channel, req DeadLetterReplayRequest) (*DeadLetterReplayResponse, error)
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/ckshitij/notify-srv/internal/config"
@@ -16,14 +15,31 @@ import (
 	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
+// deliveryTimeout bounds a single delivery, rendering and sending, so the
+// scheduler can tell a notification stuck in sending from a slow one.
+const deliveryTimeout = 2 * time.Minute
+
+// DeadLetterReplayer reads dead-letter messages back and republishes them.
+type DeadLetterReplayer interface {
+	Read(ctx context.Context, dlqTopic string, partition int32, from, to int64) ([]kafka.DeadLetter, error)
+	Publish(targetTopic string, letters []kafka.DeadLetter) (int, error)
+}
+
 type serviceImpl struct {
-	repo         Repository
-	renderer     renderer.Renderer
-	senders      map[shared.Channel]Sender
-	templateRepo template.TemplateRepository
-	log          logger.Logger
-	producer     *kafka.Producer
-	kafkaCfg     *config.KafkaConfig
+	repo           Repository
+	renderer       renderer.Renderer
+	senders        map[shared.Channel]Sender
+	templateRepo   template.TemplateRepository
+	log            logger.Logger
+	replayer       DeadLetterReplayer
+	kafkaCfg       *config.KafkaConfig
+	retryCfg       *config.RetryConfig
+	idempotencyCfg *config.IdempotencyConfig
+	batchCfg       *config.BatchConfig
+	unsubscribeCfg *config.UnsubscribeConfig
+	attachmentCfg  *config.AttachmentConfig
+	preferences    UserPreferences
+	suppressions   SuppressionList
 }
 
 func NewNotificationService(
@@ -32,72 +48,124 @@ func NewNotificationService(
 	senders map[shared.Channel]Sender,
 	templateRepo template.TemplateRepository,
 	log logger.Logger,
-	producer *kafka.Producer,
+	replayer DeadLetterReplayer,
 	kafkaCfg *config.KafkaConfig,
+	retryCfg *config.RetryConfig,
+	idempotencyCfg *config.IdempotencyConfig,
+	batchCfg *config.BatchConfig,
+	unsubscribeCfg *config.UnsubscribeConfig,
+	attachmentCfg *config.AttachmentConfig,
+	preferences UserPreferences,
+	suppressions SuppressionList,
 ) Service {
-	return &serviceImpl{repo, renderer, senders, templateRepo, log, producer, kafkaCfg}
+	return &serviceImpl{repo, renderer, senders, templateRepo, log, replayer, kafkaCfg, retryCfg, idempotencyCfg, batchCfg, unsubscribeCfg, attachmentCfg, preferences, suppressions}
 }
 
 func (s *serviceImpl) SendNow(ctx context.Context, n *Notification) (int64, error) {
-	// 1. Persist notification first (source of truth)
 	n.Status = StatusPending
-
-	id, err := s.repo.Create(ctx, n)
-	if err != nil {
-		return -1, err
-	}
-
-	// 2. Serialize payload (notification ID only)
-	msg, err := json.Marshal(id)
-	if err != nil {
-		s.log.Error(ctx, "failed to marshal notification id", logger.Error(err))
-		return -1, err
+	if n.Priority == "" {
+		n.Priority = PriorityNormal
 	}
 
-	// 3. Resolve Kafka topic by channel
+	// Resolve Kafka topic by channel
 	topic, ok := s.kafkaCfg.Topics[string(n.Channel)]
 	if !ok {
 		return -1, fmt.Errorf("kafka topic not found for channel %s", n.Channel)
 	}
 
-	// 4. Use notification ID as Kafka key (ordering + idempotency)
-	key := strconv.FormatInt(id, 10)
-
-	_, _, err = s.producer.SendMessage(topic, key, msg)
-	if err != nil {
-		s.log.Error(
-			ctx,
-			"failed to send message to kafka",
-			logger.Error(err),
-			logger.Int64("notification_id", id),
-			logger.String("topic", topic),
-		)
+	if err := s.prepare(ctx, n); err != nil {
 		return -1, err
 	}
 
-	return id, nil
+	// Persist the notification and its outbox entry in one transaction;
+	// the OutboxRelay publishes it to Kafka.
+	return s.repo.CreateWithOutbox(ctx, n, topic)
 }
 
 func (s *serviceImpl) Schedule(ctx context.Context, n *Notification, when time.Time) (int64, error) {
 
+	if when.IsZero() {
+		return -1, shared.ErrRequiredFieldScheduledAt
+	}
+
 	n.Status = StatusScheduled
 	n.ScheduledAt = &when
+	if n.Priority == "" {
+		n.Priority = PriorityNormal
+	}
+
+	if err := s.prepare(ctx, n); err != nil {
+		return -1, err
+	}
 
 	return s.repo.Create(ctx, n)
 }
 
+// prepare runs the checks every send path makes before persisting a
+// notification: it validates n against its channel sender and resolves the
+// templates of its fallback chain.
+func (s *serviceImpl) prepare(ctx context.Context, n *Notification) error {
+	if err := s.validateForSender(ctx, n); err != nil {
+		return err
+	}
+	return s.resolveFallbackTemplates(ctx, n)
+}
+
+func (s *serviceImpl) Cancel(ctx context.Context, notificationID int64) error {
+	cancelled, err := s.repo.Cancel(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		return nil
+	}
+	return s.ensureScheduled(ctx, notificationID)
+}
+
+func (s *serviceImpl) Reschedule(ctx context.Context, notificationID int64, when time.Time) error {
+	if when.IsZero() {
+		return shared.ErrRequiredFieldScheduledAt
+	}
+
+	rescheduled, err := s.repo.Reschedule(ctx, notificationID, when)
+	if err != nil {
+		return err
+	}
+	if rescheduled {
+		return nil
+	}
+	// MySQL reports no affected rows when scheduled_at is unchanged
+	return s.ensureScheduled(ctx, notificationID)
+}
+
+// ensureScheduled explains why a conditional update on a scheduled notification
+// matched no rows: the notification does not exist or has left the scheduled state.
+func (s *serviceImpl) ensureScheduled(ctx context.Context, notificationID int64) error {
+	n, err := s.repo.GetByID(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	if n.Status != StatusScheduled {
+		return shared.ErrNotificationNotScheduled
+	}
+	return nil
+}
+
 func (s *serviceImpl) Process(
 	ctx context.Context,
 	notificationID int64,
 ) error {
 
+	// Only one delivery of the message gets to send: a duplicate, e.g. one
+	// republished by the outbox relay, or a notification that is already sent,
+	// cancelled or not yet due is skipped.
 	acquired, err := s.repo.AcquireForSending(ctx, notificationID)
 	if err != nil {
 		return err
 	}
 	if !acquired {
-		s.log.Warn(ctx, "failed to aquire the record", logger.String("status", "sending"), logger.Int64("notificationID", notificationID))
-		// return nil
+		s.log.Info(ctx, "skipping notification not in a sendable state", logger.Int64("notificationID", notificationID))
+		return nil
 	}
 
 	n, err := s.repo.GetByID(ctx, notificationID)
@@ -105,16 +173,100 @@ func (s *serviceImpl) Process(
 		return err
 	}
 
+	if n.Status != StatusSending {
+		s.log.Info(ctx, "skipping notification changed while acquiring",
+			logger.Int64("notificationID", notificationID),
+			logger.String("status", string(n.Status)),
+		)
+		return nil
+	}
+
+	suppressed, err := s.checkSuppressionList(ctx, n)
+	if err != nil {
+		return s.handleFailure(ctx, n, err)
+	}
+	if suppressed {
+		return nil
+	}
+
+	if s.applyPreferences(ctx, n) {
+		return nil
+	}
+
+	if s.deferForQuietHours(ctx, n) {
+		return nil
+	}
+
+	if n.DigestKey != "" {
+		return s.deliverDigest(ctx, n)
+	}
+
+	attempt := NotificationAttempt{
+		NotificationID: n.ID,
+		Attempt:        n.Attempts,
+		Sender:         string(n.Channel),
+		StartedAt:      time.Now(),
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	result, err := s.deliver(deliverCtx, n)
+	cancel()
+	s.recordAttempt(ctx, attempt, result, err)
+	if err != nil {
+		return s.handleFailure(ctx, n, err)
+	}
+
+	return s.markSent(ctx, n, result)
+}
+
+// markSent records a delivered notification. One the scheduler reclaimed
+// while it was sending belongs to the newer delivery and is left alone.
+func (s *serviceImpl) markSent(ctx context.Context, n *Notification, result SendResult) error {
+	marked, err := s.repo.MarkSent(ctx, n.ID, time.Now(), result)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		s.log.Warn(ctx, "notification was reclaimed while sending, not marking it sent",
+			logger.Int64("notificationID", n.ID),
+		)
+	}
+	return nil
+}
+
+// deliver renders the notification template and hands it to the channel sender.
+// Failures that a retry cannot fix are wrapped as permanent.
+func (s *serviceImpl) deliver(ctx context.Context, n *Notification) (SendResult, error) {
+	content, err := s.render(ctx, n)
+	if err != nil {
+		return SendResult{}, err
+	}
+
+	if err := s.loadAttachments(ctx, n); err != nil {
+		return SendResult{}, err
+	}
+
+	if err := s.resolveReference(ctx, n); err != nil {
+		return SendResult{}, err
+	}
+
+	return s.send(ctx, n, content)
+}
+
+// render renders the notification with its template.
+func (s *serviceImpl) render(ctx context.Context, n *Notification) (renderer.RenderedTemplate, error) {
 	// Load template version
 	tplVersion, err := s.templateRepo.GetByID(ctx, n.TemplateID)
 	if err != nil || tplVersion == nil {
 		s.log.Warn(ctx, "failed to get template info",
 			logger.Int64("templateID", n.TemplateID),
-			logger.Int64("notificationID", notificationID),
-			logger.Error(err),
+			logger.Int64("notificationID", n.ID),
+			logger.Any("error", err),
 		)
-		s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
-		return err
+		if err == nil {
+			err = shared.ErrTemplateNotFound
+		}
+		return renderer.RenderedTemplate{}, permanent(err)
 	}
 
 	s.log.Info(ctx, "received data", logger.Field{
@@ -122,39 +274,187 @@ func (s *serviceImpl) Process(
 		Value: tplVersion,
 	})
 
+	data, err := s.withUnsubscribe(n, tplVersion.Category)
+	if err != nil {
+		return renderer.RenderedTemplate{}, permanent(err)
+	}
+
 	// Render content
-	content, err := s.renderer.Render(tplVersion.Subject, tplVersion.Body, n.TemplateKeyValue)
+	content, err := template.RenderContent(s.renderer, tplVersion, data)
 	if err != nil {
-		s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
-		return err
+		return renderer.RenderedTemplate{}, permanent(err)
 	}
+	return content, nil
+}
 
+func (s *serviceImpl) send(ctx context.Context, n *Notification, content renderer.RenderedTemplate) (SendResult, error) {
 	// Resolve sender
 	sender, ok := s.senders[n.Channel]
 	if !ok {
-		s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
-		return errors.New("sender not configured")
+		return SendResult{}, permanent(errors.New("sender not configured"))
 	}
 
 	// Send
-	if err := sender.Send(ctx, *n, content); err != nil {
-		s.repo.UpdateStatus(ctx, n.ID, StatusFailed)
-		return err
+	return sender.Send(ctx, *n, content)
+}
+
+// recordAttempt stores the outcome of a single delivery attempt. History is best
+// effort: a failure to write it is logged and never fails the delivery itself.
+func (s *serviceImpl) recordAttempt(ctx context.Context, attempt NotificationAttempt, result SendResult, cause error) {
+	attempt.FinishedAt = time.Now()
+	attempt.ProviderResponse = result.ProviderResponse
+	if cause != nil {
+		attempt.Error = cause.Error()
+	}
+
+	if _, err := s.repo.CreateAttempt(ctx, &attempt); err != nil {
+		s.log.Warn(ctx, "failed to record notification attempt",
+			logger.Int64("notificationID", attempt.NotificationID),
+			logger.Int("attempt", attempt.Attempt),
+			logger.Error(err),
+		)
 	}
+}
 
-	// Mark sent
-	now := time.Now()
-	if err := s.repo.MarkSent(ctx, n.ID, now); err != nil {
+// handleFailure reschedules the notification with exponential backoff while the
+// channel retry policy allows it, and only marks it failed once attempts are exhausted.
+// The error returned for a failed notification is permanent, so the consumer
+// dead-letters its message; a scheduled retry returns nil.
+func (s *serviceImpl) handleFailure(ctx context.Context, n *Notification, cause error) error {
+	var perm permanentError
+	if errors.As(cause, &perm) {
+		if err := s.markFailed(ctx, n); err != nil {
+			s.log.Error(ctx, "failed to mark notification failed", logger.Int64("notificationID", n.ID), logger.Error(err))
+		}
+		return cause
+	}
+
+	policy := s.retryCfg.ForChannel(string(n.Channel))
+	if n.Attempts >= policy.MaxAttempts {
+		s.log.Error(ctx, "notification delivery failed, attempts exhausted",
+			logger.Int64("notificationID", n.ID),
+			logger.Int("attempts", n.Attempts),
+			logger.Error(cause),
+		)
+		if err := s.markFailed(ctx, n); err != nil {
+			s.log.Error(ctx, "failed to mark notification failed", logger.Int64("notificationID", n.ID), logger.Error(err))
+		}
+		return permanent(cause)
+	}
+
+	next := time.Now().Add(nextRetryDelay(policy, n.Attempts, cause))
+	scheduled, err := s.repo.ScheduleRetry(ctx, n.ID, next)
+	if err != nil {
 		return err
 	}
+	if !scheduled {
+		s.log.Warn(ctx, "notification was reclaimed while sending, not scheduling a retry",
+			logger.Int64("notificationID", n.ID),
+			logger.Error(cause),
+		)
+		return nil
+	}
+
+	s.log.Warn(ctx, "notification delivery failed, retry scheduled",
+		logger.Int64("notificationID", n.ID),
+		logger.Int("attempts", n.Attempts),
+		logger.Any("next_attempt_at", next),
+		logger.Error(cause),
+	)
 
 	return nil
 }
 
 func (s *serviceImpl) GetByID(ctx context.Context, notificationID int64) (*Notification, error) {
-	return s.repo.GetByID(ctx, notificationID)
+	n, err := s.repo.GetByID(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.ParentID != nil || len(n.Fallback) > 0 {
+		chain, err := s.fallbackChain(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		n.Chain = chain
+	}
+	return n, nil
 }
 
 func (s *serviceImpl) List(ctx context.Context, filter NotificationFilter) ([]*Notification, error) {
 	return s.repo.List(ctx, filter)
 }
+
+func (s *serviceImpl) ListAttempts(ctx context.Context, notificationID int64) ([]*NotificationAttempt, error) {
+	if _, err := s.repo.GetByID(ctx, notificationID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAttempts(ctx, notificationID)
+}
+
+// ReplayDeadLetters republishes an offset range of a dead-letter topic.
+// Dead-lettered notifications failed permanently, so before republishing they
+// are moved back to pending with their attempts and status reason cleared;
+// otherwise Process would not acquire them and the replay would be dropped.
+// Messages that do not decode to a notification ID are skipped and reported.
+func (s *serviceImpl) ReplayDeadLetters(ctx context.Context, channel shared.Channel, req DeadLetterReplayRequest) (*DeadLetterReplayResponse, error) {
+	if req.FromOffset < 0 || req.ToOffset < req.FromOffset || req.Partition < 0 {
+		return nil, shared.ErrInvalidOffsetRange
+	}
+
+	dlqTopic, ok := s.kafkaCfg.DeadLetterTopics[string(channel)]
+	if !ok {
+		return nil, shared.ErrDeadLetterNotConfigured
+	}
+
+	topic, ok := s.kafkaCfg.Topics[string(channel)]
+	if !ok {
+		return nil, fmt.Errorf("kafka topic not found for channel %s", channel)
+	}
+
+	letters, err := s.replayer.Read(ctx, dlqTopic, req.Partition, req.FromOffset, req.ToOffset)
+	if err != nil {
+		s.log.Error(ctx, "failed to read dead-letter messages",
+			logger.String("dlq_topic", dlqTopic),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	resp := &DeadLetterReplayResponse{}
+	replay := make([]kafka.DeadLetter, 0, len(letters))
+	ids := make([]int64, 0, len(letters))
+	for _, l := range letters {
+		var id int64
+		if err := json.Unmarshal(l.Value, &id); err != nil {
+			resp.Skipped = append(resp.Skipped, l.Source)
+			continue
+		}
+		replay = append(replay, l)
+		ids = append(ids, id)
+	}
+
+	if resp.Reset, err = s.repo.ResetFailed(ctx, ids); err != nil {
+		return nil, err
+	}
+
+	resp.Replayed, err = s.replayer.Publish(topic, replay)
+	if err != nil {
+		s.log.Error(ctx, "failed to replay dead-letter messages",
+			logger.String("dlq_topic", dlqTopic),
+			logger.Int("replayed", resp.Replayed),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "replayed dead-letter messages",
+		logger.String("dlq_topic", dlqTopic),
+		logger.String("topic", topic),
+		logger.Int("replayed", resp.Replayed),
+		logger.Int("reset", resp.Reset),
+		logger.Int("skipped", len(resp.Skipped)),
+	)
+
+	return resp, nil
+}
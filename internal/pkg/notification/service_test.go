@@ -0,0 +1,278 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/pkg/template"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...logger.Field) {}
+func (nopLogger) Info(context.Context, string, ...logger.Field)  {}
+func (nopLogger) Warn(context.Context, string, ...logger.Field)  {}
+func (nopLogger) Error(context.Context, string, ...logger.Field) {}
+func (nopLogger) Fatal(context.Context, string, ...logger.Field) {}
+
+// fakeRepo records the writes of the service. Methods a test does not expect
+// are left to the embedded nil Repository and panic.
+type fakeRepo struct {
+	Repository
+	notifications map[int64]*Notification
+	statuses      map[int64]NotificationStatus
+	retries       map[int64]time.Time
+	attempts      []*NotificationAttempt
+	quietHours    *QuietHours
+}
+
+func newFakeRepo(ns ...*Notification) *fakeRepo {
+	r := &fakeRepo{
+		notifications: map[int64]*Notification{},
+		statuses:      map[int64]NotificationStatus{},
+		retries:       map[int64]time.Time{},
+	}
+	for _, n := range ns {
+		r.notifications[n.ID] = n
+		r.statuses[n.ID] = n.Status
+	}
+	return r
+}
+
+func (r *fakeRepo) GetByID(_ context.Context, id int64) (*Notification, error) {
+	n, ok := r.notifications[id]
+	if !ok {
+		return nil, shared.ErrRecordNotFound
+	}
+	cp := *n
+	cp.Status = r.statuses[id]
+	return &cp, nil
+}
+
+func (r *fakeRepo) AcquireForSending(_ context.Context, id int64) (bool, error) {
+	switch r.statuses[id] {
+	case StatusPending, StatusScheduled:
+		r.statuses[id] = StatusSending
+		r.notifications[id].Attempts++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeRepo) UpdateStatus(_ context.Context, id int64, status NotificationStatus) error {
+	r.statuses[id] = status
+	return nil
+}
+
+func (r *fakeRepo) MarkSent(_ context.Context, id int64, _ time.Time, _ SendResult) (bool, error) {
+	if r.statuses[id] != StatusSending {
+		return false, nil
+	}
+	r.statuses[id] = StatusSent
+	return true, nil
+}
+
+func (r *fakeRepo) GetQuietHours(context.Context, shared.Channel, string) (*QuietHours, error) {
+	if r.quietHours == nil {
+		return nil, shared.ErrRecordNotFound
+	}
+	return r.quietHours, nil
+}
+
+func (r *fakeRepo) DeferSending(_ context.Context, id int64, until time.Time, _ string) (bool, error) {
+	if r.statuses[id] != StatusSending {
+		return false, nil
+	}
+	r.statuses[id] = StatusScheduled
+	r.retries[id] = until
+	return true, nil
+}
+
+func (r *fakeRepo) CreateAttempt(_ context.Context, a *NotificationAttempt) (int64, error) {
+	a.ID = int64(len(r.attempts) + 1)
+	r.attempts = append(r.attempts, a)
+	return a.ID, nil
+}
+
+func (r *fakeRepo) ListAttempts(_ context.Context, notificationID int64) ([]*NotificationAttempt, error) {
+	var out []*NotificationAttempt
+	for _, a := range r.attempts {
+		if a.NotificationID == notificationID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) ScheduleRetry(_ context.Context, id int64, next time.Time) (bool, error) {
+	if r.statuses[id] != StatusSending {
+		return false, nil
+	}
+	r.retries[id] = next
+	r.statuses[id] = StatusScheduled
+	return true, nil
+}
+
+type fakeTemplates struct {
+	template.TemplateRepository
+}
+
+func (fakeTemplates) GetByID(_ context.Context, id int64) (*template.Template, error) {
+	return &template.Template{ID: id, Channel: shared.ChannelInApp, Category: template.DefaultCategory, Body: "Hi {{.Name}}"}, nil
+}
+
+// fakeSender fails with the queued errors, then succeeds.
+type fakeSender struct {
+	errs []error
+	sent []renderer.RenderedTemplate
+}
+
+func (f *fakeSender) Send(_ context.Context, _ Notification, content renderer.RenderedTemplate) (SendResult, error) {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return SendResult{ProviderResponse: "503 Service Unavailable"}, err
+	}
+	f.sent = append(f.sent, content)
+	return SendResult{ProviderResponse: "ok"}, nil
+}
+
+func newTestService(repo Repository) *serviceImpl {
+	return &serviceImpl{
+		repo:         repo,
+		renderer:     renderer.NewGoTemplateRenderer(),
+		senders:      map[shared.Channel]Sender{},
+		templateRepo: fakeTemplates{},
+		log:          nopLogger{},
+		kafkaCfg:     &config.KafkaConfig{},
+		retryCfg:     &config.RetryConfig{Default: config.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}},
+	}
+}
+
+func inAppNotification(id int64) *Notification {
+	user := "42"
+	return &Notification{
+		ID:               id,
+		Channel:          shared.ChannelInApp,
+		TemplateID:       7,
+		Recipient:        NotificationRecipient{InAppUser: &user},
+		TemplateKeyValue: map[string]any{"Name": "Ada"},
+		Status:           StatusPending,
+	}
+}
+
+func TestHandleFailureWrappedPermanent(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+
+	cause := errors.New("no such mailbox")
+	err := s.handleFailure(context.Background(), &Notification{ID: 1, Attempts: 1}, fmt.Errorf("send: %w", Permanent(cause)))
+	require.ErrorIs(t, err, cause)
+	require.ErrorAs(t, err, new(interface{ Permanent() bool }))
+	require.Equal(t, StatusFailed, repo.statuses[1])
+	require.Empty(t, repo.retries)
+}
+
+func TestHandleFailureExhausted(t *testing.T) {
+	repo := newFakeRepo()
+	s := newTestService(repo)
+
+	err := s.handleFailure(context.Background(), &Notification{ID: 1, Attempts: 3}, errors.New("timeout"))
+	require.ErrorAs(t, err, new(interface{ Permanent() bool }))
+	require.Equal(t, StatusFailed, repo.statuses[1])
+}
+
+func TestHandleFailureRetries(t *testing.T) {
+	repo := newFakeRepo(&Notification{ID: 1, Status: StatusSending})
+	s := newTestService(repo)
+
+	require.NoError(t, s.handleFailure(context.Background(), &Notification{ID: 1, Attempts: 1}, errors.New("timeout")))
+	require.Contains(t, repo.retries, int64(1))
+	require.Equal(t, StatusScheduled, repo.statuses[1])
+}
+
+// reclaimSender lets the scheduler reclaim the notification while it sends.
+type reclaimSender struct {
+	fakeSender
+	repo   *fakeRepo
+	status NotificationStatus
+}
+
+func (r *reclaimSender) Send(ctx context.Context, n Notification, content renderer.RenderedTemplate) (SendResult, error) {
+	r.repo.statuses[n.ID] = r.status
+	return r.fakeSender.Send(ctx, n, content)
+}
+
+func TestProcessLateResultKeepsReclaimedStatus(t *testing.T) {
+	for _, err := range []error{nil, errors.New("timeout")} {
+		repo := newFakeRepo(inAppNotification(1))
+		sender := &reclaimSender{repo: repo, status: StatusDispatched}
+		if err != nil {
+			sender.errs = []error{err}
+		}
+		s := newTestService(repo)
+		s.senders[shared.ChannelInApp] = sender
+
+		require.NoError(t, s.Process(context.Background(), 1))
+		require.Equal(t, StatusDispatched, repo.statuses[1], err)
+		require.Empty(t, repo.retries, err)
+	}
+}
+
+func TestProcessRecordsOneAttemptPerSend(t *testing.T) {
+	repo := newFakeRepo(inAppNotification(1))
+	sender := &fakeSender{errs: []error{errors.New("provider unavailable")}}
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Len(t, repo.attempts, 1)
+	require.Equal(t, "provider unavailable", repo.attempts[0].Error)
+	require.Equal(t, "503 Service Unavailable", repo.attempts[0].ProviderResponse)
+	require.Equal(t, string(shared.ChannelInApp), repo.attempts[0].Sender)
+	require.Contains(t, repo.retries, int64(1))
+
+	// the scheduler picks the retry up again
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Len(t, repo.attempts, 2)
+	require.Empty(t, repo.attempts[1].Error)
+	require.Equal(t, "ok", repo.attempts[1].ProviderResponse)
+	require.Equal(t, StatusSent, repo.statuses[1])
+	require.Equal(t, "Hi Ada", sender.sent[0].Body)
+}
+
+func TestProcessSkipsNotAcquired(t *testing.T) {
+	for _, status := range []NotificationStatus{StatusSent, StatusSending, StatusCancelled, StatusFailed} {
+		n := inAppNotification(1)
+		n.Status = status
+		repo := newFakeRepo(n)
+		sender := &fakeSender{}
+		s := newTestService(repo)
+		s.senders[shared.ChannelInApp] = sender
+
+		// e.g. the outbox relay published the message again
+		require.NoError(t, s.Process(context.Background(), 1), status)
+		require.Empty(t, sender.sent, status)
+		require.Empty(t, repo.attempts, status)
+		require.Equal(t, status, repo.statuses[1])
+	}
+}
+
+func TestProcessSendsOnce(t *testing.T) {
+	repo := newFakeRepo(inAppNotification(1))
+	sender := &fakeSender{}
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Len(t, sender.sent, 1)
+}
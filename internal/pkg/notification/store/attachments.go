@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+)
+
+func (r *notificationStore) insertAttachment(ctx context.Context, db execer, notificationID int64, a notification.Attachment) error {
+	// URL attachments have no content until they are fetched for delivery
+	var content []byte
+	if a.URL == "" {
+		content = a.Content
+	}
+
+	_, err := db.ExecContext(ctx, "CreateNotificationAttachment", CreateNotificationAttachmentQuery,
+		notificationID,
+		a.Filename,
+		a.ContentType,
+		a.URL,
+		a.Inline,
+		a.ContentID,
+		a.Size,
+		content,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to create notification attachment", logger.Int64("notificationID", notificationID), logger.Error(err))
+	}
+	return err
+}
+
+func (r *notificationStore) ListAttachments(ctx context.Context, notificationID int64) ([]notification.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx, "ListNotificationAttachments", ListNotificationAttachmentsQuery, notificationID)
+	if err != nil {
+		r.log.Error(ctx, "failed to list notification attachments", logger.Int64("notificationID", notificationID), logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []notification.Attachment
+	for rows.Next() {
+		var a notification.Attachment
+		if err := rows.Scan(
+			&a.ID,
+			&a.Filename,
+			&a.ContentType,
+			&a.URL,
+			&a.Inline,
+			&a.ContentID,
+			&a.Size,
+			&a.Content,
+		); err != nil {
+			r.log.Error(ctx, "failed to scan notification attachments", logger.Error(err))
+			return nil, err
+		}
+		out = append(out, a)
+	}
+
+	return out, rows.Err()
+}
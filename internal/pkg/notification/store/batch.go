@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+const defaultInsertChunkSize = 500
+
+// CreateBatch stores the batch, its notifications (chunkSize rows per INSERT)
+// and their outbox entries in one transaction, and sets the generated IDs on ns
+// in order.
+func (r *notificationStore) CreateBatch(ctx context.Context, b *notification.NotificationBatch, ns []*notification.Notification, topic string, chunkSize int) error {
+	if chunkSize <= 0 {
+		chunkSize = defaultInsertChunkSize
+	}
+
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		res, err := tx.ExecContext(ctx, "CreateNotificationBatch", CreateNotificationBatchQuery,
+			b.Channel,
+			b.TemplateID,
+			b.Total,
+			b.Accepted,
+		)
+		if err != nil {
+			return err
+		}
+		b.ID, _ = res.LastInsertId()
+
+		for start := 0; start < len(ns); start += chunkSize {
+			query, args, err := buildBatchInsertNotificationsQuery(b.ID, ns[start:min(start+chunkSize, len(ns))])
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, "CreateBatchNotifications", query, args...); err != nil {
+				if isFKViolation(err) {
+					return shared.ErrTemplateNotFound
+				}
+				return err
+			}
+		}
+
+		// Auto increment values are not guaranteed to be contiguous across a
+		// multi-row INSERT, but they are increasing, so read them back in order.
+		rows, err := tx.QueryContext(ctx, "ListBatchNotificationIDs", ListBatchNotificationIDsQuery, b.ID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		i := 0
+		for rows.Next() {
+			if i >= len(ns) {
+				return fmt.Errorf("batch %d has more notifications than were inserted", b.ID)
+			}
+			if err := rows.Scan(&ns[i].ID); err != nil {
+				return err
+			}
+			i++
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if i != len(ns) {
+			return fmt.Errorf("batch %d: inserted %d notifications, found %d", b.ID, len(ns), i)
+		}
+
+		_, err = tx.ExecContext(ctx, "CreateBatchOutboxMessages", CreateBatchOutboxMessagesQuery,
+			topic,
+			notification.OutboxPending,
+			b.ID,
+		)
+		return err
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to create notification batch", logger.Int("size", len(ns)), logger.Error(err))
+		return err
+	}
+
+	b.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *notificationStore) GetBatch(ctx context.Context, id int64) (*notification.NotificationBatch, error) {
+	row := r.db.QueryRowContext(ctx, "GetNotificationBatch", GetNotificationBatchQuery, id)
+
+	var b notification.NotificationBatch
+	err := row.Scan(&b.ID, &b.Channel, &b.TemplateID, &b.Total, &b.Accepted, &b.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrRecordNotFound
+		}
+		r.log.Error(ctx, "failed to get notification batch", logger.Int64("batch_id", id), logger.Error(err))
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *notificationStore) CountBatchStatuses(ctx context.Context, batchID int64) (map[notification.NotificationStatus]int, error) {
+	rows, err := r.db.QueryContext(ctx, "CountBatchStatuses", CountBatchStatusesQuery, batchID)
+	if err != nil {
+		r.log.Error(ctx, "failed to count batch statuses", logger.Int64("batch_id", batchID), logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[notification.NotificationStatus]int{}
+	for rows.Next() {
+		var (
+			status notification.NotificationStatus
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func buildBatchInsertNotificationsQuery(batchID int64, ns []*notification.Notification) (string, []any, error) {
+	query := `INSERT INTO notifications (batch_id, channel, template_id, recipient, template_kv, status, priority, scheduled_at) VALUES `
+	args := make([]any, 0, len(ns)*8)
+
+	for i, n := range ns {
+		recipient, err := json.Marshal(n.Recipient)
+		if err != nil {
+			return "", nil, shared.ErrInvalidRecipient
+		}
+		payload, err := json.Marshal(n.TemplateKeyValue)
+		if err != nil {
+			return "", nil, shared.ErrInvalidTemplateKeyValue
+		}
+
+		if i > 0 {
+			query += ", "
+		}
+		query += "(" + placeholders(8) + ")"
+		args = append(args, batchID, n.Channel, n.TemplateID, recipient, payload, n.Status, n.Priority, n.ScheduledAt)
+	}
+
+	return query, args, nil
+}
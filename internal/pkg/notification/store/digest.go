@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+)
+
+// HoldForDigest is DeferSending for a notification that joins the digest
+// identified by key.
+func (r *notificationStore) HoldForDigest(ctx context.Context, id int64, until time.Time, reason, key string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "HoldNotificationForDigest", HoldNotificationForDigestQuery,
+		notification.StatusScheduled,
+		reason,
+		key,
+		until.UTC(),
+		id,
+		notification.StatusSending,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to hold notification for digest ", logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// ClaimDigest moves the scheduled and dispatched notifications of a digest,
+// other than the one delivering it, to sending and returns their ids.
+// Notifications already being sent by another delivery are left to it.
+func (r *notificationStore) ClaimDigest(ctx context.Context, key string, deliveredBy int64) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SelectDigestMembers", SelectDigestMembersQuery,
+			key,
+			deliveredBy,
+			notification.StatusScheduled,
+			notification.StatusDispatched,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		query, args := buildClaimDigestQuery(ids)
+		_, err = tx.ExecContext(ctx, "ClaimDigest", query, args...)
+		return err
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to claim digest ", logger.String("digestKey", key), logger.Error(err))
+		return nil, err
+	}
+	return ids, nil
+}
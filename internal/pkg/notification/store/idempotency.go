@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	driver "github.com/go-sql-driver/mysql"
+)
+
+func isDuplicateKey(err error) bool {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return false
+}
+
+// ReserveIdempotencyKey inserts the key and reports false when it already exists.
+func (r *notificationStore) ReserveIdempotencyKey(ctx context.Context, key *notification.IdempotencyKey) (bool, error) {
+	_, err := r.db.ExecContext(ctx, "ReserveIdempotencyKey", ReserveIdempotencyKeyQuery,
+		key.ClientID,
+		key.Key,
+		key.RequestHash,
+		key.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return false, nil
+		}
+		r.log.Error(ctx, "failed to reserve idempotency key", logger.String("client_id", key.ClientID), logger.Error(err))
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *notificationStore) GetIdempotencyKey(ctx context.Context, clientID, key string) (*notification.IdempotencyKey, error) {
+	row := r.db.QueryRowContext(ctx, "GetIdempotencyKey", GetIdempotencyKeyQuery, clientID, key)
+
+	var (
+		k        notification.IdempotencyKey
+		response []byte
+	)
+
+	err := row.Scan(&k.ClientID, &k.Key, &k.RequestHash, &response, &k.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrRecordNotFound
+		}
+		r.log.Error(ctx, "failed to get idempotency key", logger.String("client_id", clientID), logger.Error(err))
+		return nil, err
+	}
+
+	if response != nil {
+		if err := json.Unmarshal(response, &k.Response); err != nil {
+			return nil, err
+		}
+	}
+
+	return &k, nil
+}
+
+// ReclaimIdempotencyKey reserves an existing key that expired before now, and
+// reports false when another request reclaimed it first.
+func (r *notificationStore) ReclaimIdempotencyKey(ctx context.Context, key *notification.IdempotencyKey, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "ReclaimIdempotencyKey", ReclaimIdempotencyKeyQuery,
+		key.RequestHash,
+		key.ExpiresAt.UTC(),
+		key.ClientID,
+		key.Key,
+		now.UTC(),
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to reclaim idempotency key", logger.String("client_id", key.ClientID), logger.Error(err))
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request holding key, and
+// reports false when the key is no longer reserved for it.
+func (r *notificationStore) CompleteIdempotencyKey(ctx context.Context, key *notification.IdempotencyKey, resp notification.NotificationResponse, expiresAt time.Time) (bool, error) {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.db.ExecContext(ctx, "CompleteIdempotencyKey", CompleteIdempotencyKeyQuery,
+		payload,
+		expiresAt.UTC(),
+		key.ClientID,
+		key.Key,
+		key.RequestHash,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to complete idempotency key", logger.String("client_id", key.ClientID), logger.Error(err))
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteIdempotencyKey releases key, and reports false when it is no longer
+// reserved for the request.
+func (r *notificationStore) DeleteIdempotencyKey(ctx context.Context, key *notification.IdempotencyKey) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteIdempotencyKey", DeleteIdempotencyKeyQuery,
+		key.ClientID,
+		key.Key,
+		key.RequestHash,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete idempotency key", logger.String("client_id", key.ClientID), logger.Error(err))
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// CreateMessage stores the message and every child notification with its
+// outbox entry in one transaction, so a message is never partially enqueued.
+func (r *notificationStore) CreateMessage(ctx context.Context, m *notification.Message, ns []*notification.Notification, topics map[shared.Channel]string) error {
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		res, err := tx.ExecContext(ctx, "CreateMessage", CreateMessageQuery, m.TemplateName)
+		if err != nil {
+			return err
+		}
+		m.ID, _ = res.LastInsertId()
+
+		for _, n := range ns {
+			n.MessageID = &m.ID
+			id, err := r.insertNotification(ctx, tx, n)
+			if err != nil {
+				return err
+			}
+			if err := r.insertOutbox(ctx, tx, id, topics[n.Channel]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to create message", logger.String("template_name", m.TemplateName), logger.Error(err))
+		return err
+	}
+
+	m.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *notificationStore) GetMessage(ctx context.Context, id int64) (*notification.Message, error) {
+	row := r.db.QueryRowContext(ctx, "GetMessage", GetMessageQuery, id)
+
+	var m notification.Message
+	if err := row.Scan(&m.ID, &m.TemplateName, &m.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrRecordNotFound
+		}
+		r.log.Error(ctx, "failed to get message", logger.Int64("message_id", id), logger.Error(err))
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+)
+
+// insertOutbox enqueues the notification ID (JSON encoded, keyed by ID) for the relay.
+func (r *notificationStore) insertOutbox(ctx context.Context, db execer, notificationID int64, topic string) error {
+	payload, err := json.Marshal(notificationID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "CreateOutboxMessage", CreateOutboxMessageQuery,
+		notificationID,
+		topic,
+		strconv.FormatInt(notificationID, 10),
+		payload,
+		notification.OutboxPending,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to create outbox message", logger.Int64("notificationID", notificationID), logger.Error(err))
+	}
+	return err
+}
+
+// ClaimPendingOutbox leases up to limit pending messages to the caller for
+// lease, so concurrent relays publish disjoint rows.
+func (r *notificationStore) ClaimPendingOutbox(ctx context.Context, limit int, lease time.Duration) ([]notification.OutboxMessage, error) {
+	var results []notification.OutboxMessage
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		rows, err := tx.QueryContext(ctx, "FindClaimableOutbox", FindClaimableOutboxQuery, notification.OutboxPending, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var m notification.OutboxMessage
+			if err := rows.Scan(&m.ID, &m.NotificationID, &m.Topic, &m.Key, &m.Payload, &m.Attempts); err != nil {
+				return err
+			}
+			results = append(results, m)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(results))
+		for i, m := range results {
+			ids[i] = m.ID
+		}
+		query, args := buildClaimOutboxQuery(ids, time.Now().Add(lease))
+		_, err = tx.ExecContext(ctx, "ClaimOutbox", query, args...)
+		return err
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to claim pending outbox messages", logger.Int("limit", limit), logger.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *notificationStore) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args := buildMarkOutboxPublishedQuery(ids)
+	_, err := r.db.ExecContext(ctx, "MarkOutboxPublished", query, args...)
+	if err != nil {
+		r.log.Error(ctx, "failed to mark outbox messages published", logger.Int("count", len(ids)), logger.Error(err))
+	}
+	return err
+}
+
+// MarkOutboxFailed counts a failed publish and holds the message back until
+// retryAt, or marks it dead.
+func (r *notificationStore) MarkOutboxFailed(ctx context.Context, id int64, cause string, retryAt time.Time, dead bool) error {
+	status := notification.OutboxPending
+	if dead {
+		status = notification.OutboxDead
+	}
+	_, err := r.db.ExecContext(ctx, "MarkOutboxFailed", MarkOutboxFailedQuery, cause, retryAt.UTC(), status, id)
+	if err != nil {
+		r.log.Error(ctx, "failed to mark outbox message failed", logger.Int64("outboxID", id), logger.Error(err))
+	}
+	return err
+}
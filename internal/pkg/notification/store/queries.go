@@ -2,6 +2,7 @@ package store
 
 import (
 	"strings"
+	"time"
 
 	"github.com/ckshitij/notify-srv/internal/pkg/notification"
 )
@@ -9,43 +10,363 @@ import (
 const (
 	CreateNotificaionQuery = `
 		INSERT INTO notifications
-		(channel, template_id, recipient, template_kv, status, scheduled_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		(message_id, parent_id, channel, template_id, recipient, template_kv, fallback, status, priority, scheduled_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	GetNotificationByIDQuery = `
 		SELECT
-			id, channel, template_id,
-			recipient, template_kv, status,
+			id, batch_id, message_id, parent_id, channel, template_id,
+			recipient, template_kv, fallback, status, IFNULL(status_reason, ''),
+			IFNULL(provider_channel, ''), IFNULL(provider_message_id, ''), priority, attempts,
 			scheduled_at, sent_at,
-			created_at, updated_at
+			created_at, updated_at, IFNULL(digest_key, '')
 		FROM notifications
 		WHERE id = ?
 		LIMIT 1
 	`
 
+	// Of a digest only its first open notification is dispatched; it claims
+	// and delivers the others, so they are not dispatched one by one.
 	FindDueNotificationQuery = `
 		SELECT 
 			id, channel
 		FROM notifications
 		WHERE status = ?
 		  AND scheduled_at <= UTC_TIMESTAMP()
+		  AND (digest_key IS NULL OR id = (
+		    SELECT MIN(d.id) FROM notifications d
+		    WHERE d.digest_key = notifications.digest_key
+		      AND d.status IN (?, ?, ?)))
 		ORDER BY scheduled_at
 		LIMIT ?
 	`
 
-	FindStuckSendingNotificationQuery = `
+	// A dispatched row is stuck once old enough. A sending row may still be
+	// delivering, so it also needs the current attempt not to have succeeded:
+	// a recorded success means the message went out and must not be resent.
+	FindStuckNotificationsQuery = `
 		SELECT
 			id, channel
 		FROM notifications
+		WHERE (status = ? AND updated_at < NOW() - INTERVAL ? SECOND)
+		   OR (status = ? AND updated_at < NOW() - INTERVAL ? SECOND
+		       AND NOT EXISTS (
+		         SELECT 1 FROM notification_attempts a
+		         WHERE a.notification_id = notifications.id
+		           AND a.attempt = notifications.attempts
+		           AND a.error IS NULL))
+		LIMIT ?
+	`
+
+	// A scheduled notification is only sendable once due; before that a
+	// duplicate message must not send it early.
+	AcquireNotificationForSendingQuery = `
+		UPDATE notifications
+		SET status = ?, attempts = attempts + 1
+		WHERE id = ?
+		  AND (status IN (?, ?) OR (status = ? AND scheduled_at <= UTC_TIMESTAMP()))
+	`
+
+	CreateNotificationAttemptQuery = `
+		INSERT INTO notification_attempts
+		(notification_id, attempt, sender, error, provider_response, started_at, finished_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)
+	`
+
+	ListNotificationAttemptsQuery = `
+		SELECT
+			id, notification_id, attempt, sender,
+			IFNULL(error, ''), IFNULL(provider_response, ''),
+			started_at, finished_at
+		FROM notification_attempts
+		WHERE notification_id = ?
+		ORDER BY attempt, id
+	`
+
+	ReserveIdempotencyKeyQuery = `
+		INSERT INTO idempotency_keys
+		(client_id, idempotency_key, request_hash, expires_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	GetIdempotencyKeyQuery = `
+		SELECT client_id, idempotency_key, request_hash, response, expires_at
+		FROM idempotency_keys
+		WHERE client_id = ? AND idempotency_key = ?
+		LIMIT 1
+	`
+
+	// Takes over a key whose window or in-flight lease ran out; the expiry
+	// condition makes concurrent reclaims race for a single winner.
+	ReclaimIdempotencyKeyQuery = `
+		UPDATE idempotency_keys
+		SET request_hash = ?, response = NULL, expires_at = ?
+		WHERE client_id = ? AND idempotency_key = ? AND expires_at < ?
+	`
+
+	// Completing and releasing only touch a key still reserved for the same
+	// request, so a request that outlived its lease cannot overwrite or drop
+	// the reservation of the request that reclaimed the key.
+	CompleteIdempotencyKeyQuery = `
+		UPDATE idempotency_keys
+		SET response = ?, expires_at = ?
+		WHERE client_id = ? AND idempotency_key = ? AND request_hash = ? AND response IS NULL
+	`
+
+	DeleteIdempotencyKeyQuery = `
+		DELETE FROM idempotency_keys
+		WHERE client_id = ? AND idempotency_key = ? AND request_hash = ? AND response IS NULL
+	`
+
+	CreateOutboxMessageQuery = `
+		INSERT INTO notification_outbox
+		(notification_id, topic, msg_key, payload, status)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	// Rows locked by another relay are skipped rather than waited on, and
+	// rows leased or backing off until claimed_until are left alone.
+	FindClaimableOutboxQuery = `
+		SELECT id, notification_id, topic, msg_key, payload, attempts
+		FROM notification_outbox
 		WHERE status = ?
-		  AND updated_at < NOW() - INTERVAL ? SECOND
+		  AND (claimed_until IS NULL OR claimed_until <= UTC_TIMESTAMP())
+		ORDER BY id
 		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+
+	MarkOutboxFailedQuery = `
+		UPDATE notification_outbox
+		SET attempts = attempts + 1, last_error = ?, claimed_until = ?, status = ?
+		WHERE id = ?
+	`
+
+	// updated_at is set explicitly so that reclaiming a stuck dispatched row,
+	// which keeps its status, still counts as a change and restarts its clock.
+	// The stuck conditions are those of FindStuckNotificationsQuery.
+	ClaimNotificationForDispatchQuery = `
+		UPDATE notifications
+		SET status = ?, updated_at = NOW()
+		WHERE id = ?
+		  AND ((status = ? AND scheduled_at <= UTC_TIMESTAMP())
+		    OR (status = ? AND updated_at < NOW() - INTERVAL ? SECOND)
+		    OR (status = ? AND updated_at < NOW() - INTERVAL ? SECOND
+		        AND NOT EXISTS (
+		          SELECT 1 FROM notification_attempts a
+		          WHERE a.notification_id = notifications.id
+		            AND a.attempt = notifications.attempts
+		            AND a.error IS NULL)))
+	`
+
+	ReleaseNotificationDispatchQuery = `
+		UPDATE notifications
+		SET status = ?, scheduled_at = IFNULL(scheduled_at, UTC_TIMESTAMP())
+		WHERE id = ? AND status = ?
+	`
+
+	CancelNotificationQuery = `
+		UPDATE notifications
+		SET status = ?
+		WHERE id = ? AND status = ?
+	`
+
+	RescheduleNotificationQuery = `
+		UPDATE notifications
+		SET scheduled_at = ?
+		WHERE id = ? AND status = ?
+	`
+
+	CreateRecurringScheduleQuery = `
+		INSERT INTO recurring_schedules
+		(cron_expr, timezone, channel, template_id, recipient, template_kv, status, start_at, end_at, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	recurringScheduleColumns = `
+		id, cron_expr, timezone, channel, template_id,
+		recipient, template_kv, status,
+		start_at, end_at, next_run_at, last_run_at,
+		created_at, updated_at
+	`
+
+	GetRecurringScheduleByIDQuery = `SELECT ` + recurringScheduleColumns + `
+		FROM recurring_schedules
+		WHERE id = ?
+		LIMIT 1
+	`
+
+	ListRecurringSchedulesQuery = `SELECT ` + recurringScheduleColumns + `
+		FROM recurring_schedules
+		ORDER BY id
+	`
+
+	FindDueRecurringSchedulesQuery = `SELECT ` + recurringScheduleColumns + `
+		FROM recurring_schedules
+		WHERE status = ?
+		  AND next_run_at <= UTC_TIMESTAMP()
+		ORDER BY next_run_at
+		LIMIT ?
+	`
+
+	UpdateRecurringScheduleStatusQuery = `
+		UPDATE recurring_schedules
+		SET status = ?, next_run_at = ?
+		WHERE id = ? AND status = ?
+	`
+
+	AdvanceRecurringScheduleQuery = `
+		UPDATE recurring_schedules
+		SET status = ?, next_run_at = ?, last_run_at = ?
+		WHERE id = ? AND status = ? AND next_run_at = ?
+	`
+
+	DeleteRecurringScheduleQuery = `
+		DELETE FROM recurring_schedules
+		WHERE id = ?
+	`
+
+	CreateMessageQuery = `
+		INSERT INTO messages (template_name)
+		VALUES (?)
+	`
+
+	GetMessageQuery = `
+		SELECT id, template_name, created_at
+		FROM messages
+		WHERE id = ?
+		LIMIT 1
+	`
+
+	CreateNotificationBatchQuery = `
+		INSERT INTO notification_batches
+		(channel, template_id, total, accepted)
+		VALUES (?, ?, ?, ?)
+	`
+
+	GetNotificationBatchQuery = `
+		SELECT id, channel, template_id, total, accepted, created_at
+		FROM notification_batches
+		WHERE id = ?
+		LIMIT 1
+	`
+
+	ListBatchNotificationIDsQuery = `
+		SELECT id
+		FROM notifications
+		WHERE batch_id = ?
+		ORDER BY id
+	`
+
+	CountBatchStatusesQuery = `
+		SELECT status, COUNT(*)
+		FROM notifications
+		WHERE batch_id = ?
+		GROUP BY status
+	`
+
+	// The outbox payload is the JSON encoded notification ID, which for an
+	// integer is its decimal form, the same as the message key.
+	CreateBatchOutboxMessagesQuery = `
+		INSERT INTO notification_outbox
+		(notification_id, topic, msg_key, payload, status)
+		SELECT id, ?, CAST(id AS CHAR), CAST(id AS CHAR), ?
+		FROM notifications
+		WHERE batch_id = ?
+		ORDER BY id
+	`
+
+	UpsertQuietHoursQuery = `
+		INSERT INTO quiet_hours
+		(channel, recipient, timezone, start_time, end_time)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			timezone = VALUES(timezone),
+			start_time = VALUES(start_time),
+			end_time = VALUES(end_time)
+	`
+
+	GetQuietHoursQuery = `
+		SELECT
+			channel, recipient, timezone,
+			TIME_FORMAT(start_time, '%H:%i'), TIME_FORMAT(end_time, '%H:%i'),
+			updated_at
+		FROM quiet_hours
+		WHERE channel = ? AND recipient = ?
+		LIMIT 1
+	`
+
+	DeleteQuietHoursQuery = `
+		DELETE FROM quiet_hours
+		WHERE channel = ? AND recipient = ?
+	`
+
+	DeferNotificationSendingQuery = `
+		UPDATE notifications
+		SET status = ?, status_reason = ?, scheduled_at = ?, attempts = GREATEST(attempts - 1, 0)
+		WHERE id = ? AND status = ?
+	`
+
+	HoldNotificationForDigestQuery = `
+		UPDATE notifications
+		SET status = ?, status_reason = ?, digest_key = ?, scheduled_at = ?, attempts = GREATEST(attempts - 1, 0)
+		WHERE id = ? AND status = ?
+	`
+
+	// Locks the digest's other open notifications so that concurrent
+	// deliveries of one digest never both claim the same notification.
+	SelectDigestMembersQuery = `
+		SELECT id
+		FROM notifications
+		WHERE digest_key = ? AND id <> ? AND status IN (?, ?)
+		FOR UPDATE
+	`
+
+	SuppressNotificationQuery = `
+		UPDATE notifications
+		SET status = ?, status_reason = ?, attempts = GREATEST(attempts - 1, 0)
+		WHERE id = ? AND status = ?
+	`
+
+	FailNotificationQuery = `
+		UPDATE notifications
+		SET status = ?
+		WHERE id = ? AND status = ?
+	`
+
+	CreateNotificationAttachmentQuery = `
+		INSERT INTO notification_attachments
+		(notification_id, filename, content_type, url, inline, content_id, size, content)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), ?, ?)
+	`
+
+	ListNotificationAttachmentsQuery = `
+		SELECT
+			id, filename, content_type, IFNULL(url, ''),
+			inline, IFNULL(content_id, ''), size, content
+		FROM notification_attachments
+		WHERE notification_id = ?
+		ORDER BY id
+	`
+
+	// Both only apply to a notification still being sent, so a delivery that
+	// was given up on and reclaimed cannot overwrite the newer one's outcome.
+	MarkNotificationSentQuery = `
+		UPDATE notifications
+		SET status = ?, sent_at = ?, provider_channel = NULLIF(?, ''), provider_message_id = NULLIF(?, '')
+		WHERE id = ? AND status = ?
+	`
+
+	ScheduleNotificationRetryQuery = `
+		UPDATE notifications
+		SET status = ?, scheduled_at = ?
+		WHERE id = ? AND status = ?
 	`
 )
 
 func buildListNotificationsQuery(filter notification.NotificationFilter) (string, []any) {
-	query := `SELECT id, channel, template_id, recipient, template_kv, status, scheduled_at, sent_at, created_at, updated_at FROM notifications`
+	query := `SELECT id, batch_id, message_id, parent_id, channel, template_id, recipient, template_kv, fallback, status, IFNULL(status_reason, ''), IFNULL(provider_channel, ''), IFNULL(provider_message_id, ''), priority, attempts, scheduled_at, sent_at, created_at, updated_at FROM notifications`
 	args := []any{}
 	conditions := []string{}
 
@@ -57,6 +378,14 @@ func buildListNotificationsQuery(filter notification.NotificationFilter) (string
 		conditions = append(conditions, "status = ?")
 		args = append(args, *filter.Status)
 	}
+	if filter.MessageID != nil {
+		conditions = append(conditions, "message_id = ?")
+		args = append(args, *filter.MessageID)
+	}
+	if filter.ParentID != nil {
+		conditions = append(conditions, "parent_id = ?")
+		args = append(args, *filter.ParentID)
+	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
@@ -64,3 +393,48 @@ func buildListNotificationsQuery(filter notification.NotificationFilter) (string
 
 	return query, args
 }
+
+func buildClaimOutboxQuery(ids []int64, until time.Time) (string, []any) {
+	query := `UPDATE notification_outbox SET claimed_until = ? WHERE id IN (` + placeholders(len(ids)) + `)`
+	args := []any{until.UTC()}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return query, args
+}
+
+// buildResetFailedQuery moves failed notifications back to pending with a
+// fresh attempt budget; rows in any other status are left alone.
+func buildResetFailedQuery(ids []int64) (string, []any) {
+	query := `UPDATE notifications SET status = ?, attempts = 0, status_reason = NULL WHERE status = ? AND id IN (` + placeholders(len(ids)) + `)`
+	args := []any{notification.StatusPending, notification.StatusFailed}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return query, args
+}
+
+// buildClaimDigestQuery moves digest notifications locked by
+// SelectDigestMembersQuery to sending, taking an attempt for each.
+func buildClaimDigestQuery(ids []int64) (string, []any) {
+	query := `UPDATE notifications SET status = ?, attempts = attempts + 1 WHERE id IN (` + placeholders(len(ids)) + `)`
+	args := []any{notification.StatusSending}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return query, args
+}
+
+func buildMarkOutboxPublishedQuery(ids []int64) (string, []any) {
+	query := `UPDATE notification_outbox SET status = ?, published_at = UTC_TIMESTAMP() WHERE id IN (` + placeholders(len(ids)) + `)`
+	args := []any{notification.OutboxPublished}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return query, args
+}
+
+// placeholders returns "?, ?, ..." with n entries.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
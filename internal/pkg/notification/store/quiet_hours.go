@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+func (r *notificationStore) UpsertQuietHours(ctx context.Context, q *notification.QuietHours) error {
+	_, err := r.db.ExecContext(ctx, "UpsertQuietHours", UpsertQuietHoursQuery,
+		q.Channel,
+		q.Recipient,
+		q.Timezone,
+		q.Start,
+		q.End,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to upsert quiet hours", logger.String("channel", string(q.Channel)), logger.Error(err))
+		return err
+	}
+	q.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *notificationStore) GetQuietHours(ctx context.Context, channel shared.Channel, recipient string) (*notification.QuietHours, error) {
+	row := r.db.QueryRowContext(ctx, "GetQuietHours", GetQuietHoursQuery, channel, recipient)
+
+	var q notification.QuietHours
+	err := row.Scan(&q.Channel, &q.Recipient, &q.Timezone, &q.Start, &q.End, &q.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrRecordNotFound
+		}
+		r.log.Error(ctx, "failed to get quiet hours", logger.String("channel", string(channel)), logger.Error(err))
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *notificationStore) DeleteQuietHours(ctx context.Context, channel shared.Channel, recipient string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteQuietHours", DeleteQuietHoursQuery, channel, recipient)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete quiet hours", logger.String("channel", string(channel)), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// DeferSending hands a notification that is being sent back to the scheduler,
+// recording why. The attempt taken when it was acquired is given back, since
+// nothing was delivered. It reports false when the notification was no longer
+// being sent.
+func (r *notificationStore) DeferSending(ctx context.Context, id int64, until time.Time, reason string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeferNotificationSending", DeferNotificationSendingQuery,
+		notification.StatusScheduled,
+		reason,
+		until.UTC(),
+		id,
+		notification.StatusSending,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to defer notification ", logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// MarkSuppressed ends a notification that is being sent without delivering
+// it, e.g. because the recipient opted out of it.
+func (r *notificationStore) MarkSuppressed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, "SuppressNotification", SuppressNotificationQuery,
+		notification.StatusSuppressed,
+		reason,
+		id,
+		notification.StatusSending,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to suppress notification ", logger.Int64("notificationID", id), logger.Error(err))
+	}
+	return err
+}
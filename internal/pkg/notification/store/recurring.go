@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecurring(row scanner) (*notification.RecurringSchedule, error) {
+	var (
+		rs        notification.RecurringSchedule
+		recipient []byte
+		payload   []byte
+	)
+
+	if err := row.Scan(
+		&rs.ID,
+		&rs.CronExpr,
+		&rs.Timezone,
+		&rs.Channel,
+		&rs.TemplateID,
+		&recipient,
+		&payload,
+		&rs.Status,
+		&rs.StartAt,
+		&rs.EndAt,
+		&rs.NextRunAt,
+		&rs.LastRunAt,
+		&rs.CreatedAt,
+		&rs.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(recipient, &rs.Recipient); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &rs.TemplateKeyValue); err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+func (r *notificationStore) CreateRecurring(ctx context.Context, rs *notification.RecurringSchedule) (int64, error) {
+	recipient, err := json.Marshal(rs.Recipient)
+	if err != nil {
+		return -1, shared.ErrInvalidRecipient
+	}
+
+	payload, err := json.Marshal(rs.TemplateKeyValue)
+	if err != nil {
+		return -1, shared.ErrInvalidTemplateKeyValue
+	}
+
+	res, err := r.db.ExecContext(ctx, "CreateRecurringSchedule", CreateRecurringScheduleQuery,
+		rs.CronExpr,
+		rs.Timezone,
+		rs.Channel,
+		rs.TemplateID,
+		recipient,
+		payload,
+		rs.Status,
+		utcOrNil(rs.StartAt),
+		utcOrNil(rs.EndAt),
+		utcOrNil(rs.NextRunAt),
+	)
+	if err != nil {
+		if isFKViolation(err) {
+			return -1, shared.ErrTemplateNotFound
+		}
+		r.log.Error(ctx, "failed to create recurring schedule", logger.Error(err))
+		return -1, err
+	}
+
+	id, _ := res.LastInsertId()
+	rs.ID = id
+	return id, nil
+}
+
+func (r *notificationStore) GetRecurring(ctx context.Context, id int64) (*notification.RecurringSchedule, error) {
+	row := r.db.QueryRowContext(ctx, "GetRecurringScheduleByID", GetRecurringScheduleByIDQuery, id)
+
+	rs, err := scanRecurring(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrRecordNotFound
+		}
+		r.log.Error(ctx, "failed to get recurring schedule", logger.Int64("recurring_schedule_id", id), logger.Error(err))
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (r *notificationStore) ListRecurring(ctx context.Context) ([]*notification.RecurringSchedule, error) {
+	return r.queryRecurring(ctx, "ListRecurringSchedules", ListRecurringSchedulesQuery)
+}
+
+func (r *notificationStore) FindDueRecurring(ctx context.Context, limit int) ([]*notification.RecurringSchedule, error) {
+	return r.queryRecurring(ctx, "FindDueRecurringSchedules", FindDueRecurringSchedulesQuery, notification.RecurringActive, limit)
+}
+
+func (r *notificationStore) queryRecurring(ctx context.Context, queryName, query string, args ...any) ([]*notification.RecurringSchedule, error) {
+	rows, err := r.db.QueryContext(ctx, queryName, query, args...)
+	if err != nil {
+		r.log.Error(ctx, "failed to query recurring schedules", logger.String("query", queryName), logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out = []*notification.RecurringSchedule{}
+	for rows.Next() {
+		rs, err := scanRecurring(rows)
+		if err != nil {
+			r.log.Error(ctx, "failed to scan recurring schedules", logger.Error(err))
+			return nil, err
+		}
+		out = append(out, rs)
+	}
+
+	return out, rows.Err()
+}
+
+func (r *notificationStore) UpdateRecurringStatus(ctx context.Context, id int64, from, to notification.RecurringStatus, nextRunAt *time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UpdateRecurringScheduleStatus", UpdateRecurringScheduleStatusQuery, to, utcOrNil(nextRunAt), id, from)
+	if err != nil {
+		r.log.Error(ctx, "failed to update recurring schedule status", logger.Int64("recurring_schedule_id", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *notificationStore) DeleteRecurring(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteRecurringSchedule", DeleteRecurringScheduleQuery, id)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete recurring schedule", logger.Int64("recurring_schedule_id", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// MaterializeRecurring advances the schedule past its current run and creates the
+// notification for that run in one transaction. The update is conditional on the
+// run it was read with, so concurrent schedulers materialize each run only once;
+// it reports false when another scheduler got there first.
+func (r *notificationStore) MaterializeRecurring(ctx context.Context, rs *notification.RecurringSchedule, n *notification.Notification, nextRunAt *time.Time) (bool, error) {
+	status := notification.RecurringActive
+	if nextRunAt == nil {
+		status = notification.RecurringCompleted
+	}
+
+	advanced := false
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		res, err := tx.ExecContext(ctx, "AdvanceRecurringSchedule", AdvanceRecurringScheduleQuery,
+			status,
+			utcOrNil(nextRunAt),
+			rs.NextRunAt.UTC(),
+			rs.ID,
+			notification.RecurringActive,
+			rs.NextRunAt.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil || rows != 1 {
+			return err
+		}
+
+		if _, err := r.insertNotification(ctx, tx, n); err != nil {
+			return err
+		}
+		advanced = true
+		return nil
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to materialize recurring schedule", logger.Int64("recurring_schedule_id", rs.ID), logger.Error(err))
+		return false, err
+	}
+
+	return advanced, nil
+}
@@ -31,7 +31,44 @@ func NewNotificationRepository(db *mysqlwrapper.DB, log logger.Logger) *notifica
 	return &notificationStore{db, log}
 }
 
+// execer is satisfied by both *mysqlwrapper.DB and *mysqlwrapper.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, queryName, query string, args ...any) (sql.Result, error)
+}
+
 func (r *notificationStore) Create(ctx context.Context, n *notification.Notification) (int64, error) {
+	if len(n.Attachments) == 0 {
+		return r.insertNotification(ctx, r.db, n)
+	}
+
+	// attachments are written in the same transaction as their notification
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		_, err := r.insertNotification(ctx, tx, n)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+	return n.ID, nil
+}
+
+// CreateWithOutbox persists the notification together with the outbox entry that
+// publishes it, so a notification is never stored without being enqueued.
+func (r *notificationStore) CreateWithOutbox(ctx context.Context, n *notification.Notification, topic string) (int64, error) {
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		id, err := r.insertNotification(ctx, tx, n)
+		if err != nil {
+			return err
+		}
+		return r.insertOutbox(ctx, tx, id, topic)
+	})
+	if err != nil {
+		return -1, err
+	}
+	return n.ID, nil
+}
+
+func (r *notificationStore) insertNotification(ctx context.Context, db execer, n *notification.Notification) (int64, error) {
 
 	recipient, err := json.Marshal(n.Recipient)
 	if err != nil {
@@ -45,12 +82,23 @@ func (r *notificationStore) Create(ctx context.Context, n *notification.Notifica
 		return -1, shared.ErrInvalidTemplateKeyValue
 	}
 
-	res, err := r.db.ExecContext(ctx, "CreateNotification", CreateNotificaionQuery,
+	var fallback []byte
+	if len(n.Fallback) > 0 {
+		if fallback, err = json.Marshal(n.Fallback); err != nil {
+			return -1, err
+		}
+	}
+
+	res, err := db.ExecContext(ctx, "CreateNotification", CreateNotificaionQuery,
+		n.MessageID,
+		n.ParentID,
 		n.Channel,
 		n.TemplateID,
 		recipient,
 		payload,
+		fallback,
 		n.Status,
+		n.Priority,
 		n.ScheduledAt,
 	)
 	if err != nil {
@@ -63,6 +111,12 @@ func (r *notificationStore) Create(ctx context.Context, n *notification.Notifica
 
 	id, _ := res.LastInsertId()
 	n.ID = id
+
+	for _, a := range n.Attachments {
+		if err := r.insertAttachment(ctx, db, id, a); err != nil {
+			return -1, err
+		}
+	}
 	return id, nil
 }
 
@@ -73,19 +127,30 @@ func (r *notificationStore) GetByID(ctx context.Context, id int64) (*notificatio
 		n         notification.Notification
 		recipient []byte
 		payload   []byte
+		fallback  []byte
 	)
 
 	err := row.Scan(
 		&n.ID,
+		&n.BatchID,
+		&n.MessageID,
+		&n.ParentID,
 		&n.Channel,
 		&n.TemplateID,
 		&recipient,
 		&payload,
+		&fallback,
 		&n.Status,
+		&n.StatusReason,
+		&n.ProviderChannel,
+		&n.ProviderMessageID,
+		&n.Priority,
+		&n.Attempts,
 		&n.ScheduledAt,
 		&n.SentAt,
 		&n.CreatedAt,
 		&n.UpdatedAt,
+		&n.DigestKey,
 	)
 
 	if err != nil {
@@ -106,12 +171,24 @@ func (r *notificationStore) GetByID(ctx context.Context, id int64) (*notificatio
 		return nil, err
 	}
 
+	if fallback != nil {
+		if err := json.Unmarshal(fallback, &n.Fallback); err != nil {
+			return nil, err
+		}
+	}
+
 	return &n, nil
 }
 
 func (r *notificationStore) FindDue(ctx context.Context, limit int) ([]notification.NotificationScheduled, error) {
 
-	rows, err := r.db.QueryContext(ctx, "FindDueNotifications", FindDueNotificationQuery, notification.StatusScheduled, limit)
+	rows, err := r.db.QueryContext(ctx, "FindDueNotifications", FindDueNotificationQuery,
+		notification.StatusScheduled,
+		notification.StatusScheduled,
+		notification.StatusDispatched,
+		notification.StatusSending,
+		limit,
+	)
 	if err != nil {
 		r.log.Error(ctx, "failed to find due notifications ", logger.Int("limit", limit), logger.Error(err))
 		return nil, err
@@ -131,11 +208,75 @@ func (r *notificationStore) FindDue(ctx context.Context, limit int) ([]notificat
 	return results, nil
 }
 
-func (r *notificationStore) FindStuckSending(ctx context.Context, olderThan time.Duration, limit int) ([]notification.NotificationScheduled, error) {
+func (r *notificationStore) CreateAttempt(ctx context.Context, a *notification.NotificationAttempt) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "CreateNotificationAttempt", CreateNotificationAttemptQuery,
+		a.NotificationID,
+		a.Attempt,
+		a.Sender,
+		a.Error,
+		a.ProviderResponse,
+		a.StartedAt.UTC(),
+		a.FinishedAt.UTC(),
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to create notification attempt", logger.Int64("notificationID", a.NotificationID), logger.Error(err))
+		return -1, err
+	}
+
+	id, _ := res.LastInsertId()
+	a.ID = id
+	return id, nil
+}
 
-	rows, err := r.db.QueryContext(ctx, "FindStuckSendingNotifications", FindStuckSendingNotificationQuery, notification.StatusSending, int(olderThan.Seconds()), limit)
+func (r *notificationStore) ListAttempts(ctx context.Context, notificationID int64) ([]*notification.NotificationAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, "ListNotificationAttempts", ListNotificationAttemptsQuery, notificationID)
 	if err != nil {
-		r.log.Error(ctx, "failed to find stuck notifications ", logger.Any("olderThan", olderThan), logger.Int("limit", limit), logger.Error(err))
+		r.log.Error(ctx, "failed to list notification attempts", logger.Int64("notificationID", notificationID), logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts = []*notification.NotificationAttempt{}
+	for rows.Next() {
+		var a notification.NotificationAttempt
+		if err := rows.Scan(
+			&a.ID,
+			&a.NotificationID,
+			&a.Attempt,
+			&a.Sender,
+			&a.Error,
+			&a.ProviderResponse,
+			&a.StartedAt,
+			&a.FinishedAt,
+		); err != nil {
+			r.log.Error(ctx, "failed to scan notification attempts", logger.Error(err))
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.Error(ctx, "failed to scan notification attempts", logger.Error(err))
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// FindStuck returns notifications left in sending or dispatched for longer
+// than olderThan, e.g. because the instance died mid-send or between claiming
+// and publishing.
+func (r *notificationStore) FindStuck(ctx context.Context, after notification.StuckAfter, limit int) ([]notification.NotificationScheduled, error) {
+
+	rows, err := r.db.QueryContext(ctx, "FindStuckNotifications", FindStuckNotificationsQuery,
+		notification.StatusDispatched,
+		int(after.Dispatched.Seconds()),
+		notification.StatusSending,
+		int(after.Sending.Seconds()),
+		limit,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to find stuck notifications ", logger.Any("after", after), logger.Int("limit", limit), logger.Error(err))
 		return nil, err
 	}
 	defer rows.Close()
@@ -169,15 +310,25 @@ func (r *notificationStore) List(ctx context.Context, filter notification.Notifi
 			n         notification.Notification
 			recipient []byte
 			payload   []byte
+			fallback  []byte
 		)
 
 		if err := rows.Scan(
 			&n.ID,
+			&n.BatchID,
+			&n.MessageID,
+			&n.ParentID,
 			&n.Channel,
 			&n.TemplateID,
 			&recipient,
 			&payload,
+			&fallback,
 			&n.Status,
+			&n.StatusReason,
+			&n.ProviderChannel,
+			&n.ProviderMessageID,
+			&n.Priority,
+			&n.Attempts,
 			&n.ScheduledAt,
 			&n.SentAt,
 			&n.CreatedAt,
@@ -189,6 +340,9 @@ func (r *notificationStore) List(ctx context.Context, filter notification.Notifi
 
 		json.Unmarshal(recipient, &n.Recipient)
 		json.Unmarshal(payload, &n.TemplateKeyValue)
+		if fallback != nil {
+			json.Unmarshal(fallback, &n.Fallback)
+		}
 
 		notifications = append(notifications, &n)
 	}
@@ -201,12 +355,25 @@ func (r *notificationStore) List(ctx context.Context, filter notification.Notifi
 	return notifications, nil
 }
 
-func (r *notificationStore) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
-	_, err := r.db.ExecContext(ctx, "MarkNotificationSent", `UPDATE notifications SET status = ?, sent_at = ? WHERE id = ?`, notification.StatusSent, sentAt, id)
+func (r *notificationStore) MarkSent(ctx context.Context, id int64, sentAt time.Time, result notification.SendResult) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "MarkNotificationSent", MarkNotificationSentQuery,
+		notification.StatusSent,
+		sentAt,
+		result.ProviderChannel,
+		result.ProviderMessageID,
+		id,
+		notification.StatusSending,
+	)
 	if err != nil {
 		r.log.Error(ctx, "failed to mark notification ", logger.String("status", "sent"), logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
 	}
-	return err
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
 }
 
 func (r *notificationStore) UpdateStatus(ctx context.Context, id int64, status notification.NotificationStatus) error {
@@ -217,12 +384,57 @@ func (r *notificationStore) UpdateStatus(ctx context.Context, id int64, status n
 	return err
 }
 
+// FailWithFallback marks the notification failed and enqueues the next channel
+// of its fallback chain in one transaction. The update is conditional on the
+// notification still being sent, so a redelivered message cannot fork the chain.
+func (r *notificationStore) FailWithFallback(ctx context.Context, id int64, next *notification.Notification, topic string) error {
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		res, err := tx.ExecContext(ctx, "FailNotification", FailNotificationQuery, notification.StatusFailed, id, notification.StatusSending)
+		if err != nil {
+			return err
+		}
+		if rows, err := res.RowsAffected(); err != nil || rows != 1 {
+			return err
+		}
+
+		nextID, err := r.insertNotification(ctx, tx, next)
+		if err != nil {
+			return err
+		}
+		return r.insertOutbox(ctx, tx, nextID, topic)
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to fail notification with fallback", logger.Int64("notificationID", id), logger.Error(err))
+	}
+	return err
+}
+
+func (r *notificationStore) ScheduleRetry(ctx context.Context, id int64, nextAttemptAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "ScheduleNotificationRetry", ScheduleNotificationRetryQuery,
+		notification.StatusScheduled,
+		nextAttemptAt.UTC(),
+		id,
+		notification.StatusSending,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to schedule notification retry ", logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
 func (r *notificationStore) AcquireForSending(ctx context.Context, id int64) (bool, error) {
 
-	res, err := r.db.ExecContext(ctx, "AcquireNotificationForSending", `UPDATE notifications SET status = ? WHERE id = ? AND status IN (?, ?)`,
+	res, err := r.db.ExecContext(ctx, "AcquireNotificationForSending", AcquireNotificationForSendingQuery,
 		notification.StatusSending,
 		id,
 		notification.StatusPending,
+		notification.StatusDispatched,
 		notification.StatusScheduled,
 	)
 	if err != nil {
@@ -237,3 +449,102 @@ func (r *notificationStore) AcquireForSending(ctx context.Context, id int64) (bo
 
 	return rows == 1, nil
 }
+
+// ClaimForDispatch moves a due scheduled notification, or one stuck in sending
+// or dispatched for longer than after allows, to dispatched, so only one scheduler
+// publishes it and a concurrent cancel or reschedule either wins cleanly or fails.
+func (r *notificationStore) ClaimForDispatch(ctx context.Context, id int64, after notification.StuckAfter) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "ClaimNotificationForDispatch", ClaimNotificationForDispatchQuery,
+		notification.StatusDispatched,
+		id,
+		notification.StatusScheduled,
+		notification.StatusDispatched,
+		int(after.Dispatched.Seconds()),
+		notification.StatusSending,
+		int(after.Sending.Seconds()),
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to claim notification ", logger.String("status", "dispatched"), logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// ReleaseDispatch hands a claimed notification back to the scheduler after a failed publish.
+func (r *notificationStore) ReleaseDispatch(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "ReleaseNotificationDispatch", ReleaseNotificationDispatchQuery,
+		notification.StatusScheduled,
+		id,
+		notification.StatusDispatched,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to release notification dispatch ", logger.Int64("notificationID", id), logger.Error(err))
+	}
+	return err
+}
+
+func (r *notificationStore) Cancel(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "CancelNotification", CancelNotificationQuery,
+		notification.StatusCancelled,
+		id,
+		notification.StatusScheduled,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to cancel notification ", logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *notificationStore) Reschedule(ctx context.Context, id int64, when time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "RescheduleNotification", RescheduleNotificationQuery,
+		when.UTC(),
+		id,
+		notification.StatusScheduled,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to reschedule notification ", logger.Int64("notificationID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// ResetFailed moves the failed notifications among ids back to pending, in a
+// single statement so that either all or none of them are reset, and returns
+// how many were.
+func (r *notificationStore) ResetFailed(ctx context.Context, ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args := buildResetFailedQuery(ids)
+	res, err := r.db.ExecContext(ctx, "ResetFailedNotifications", query, args...)
+	if err != nil {
+		r.log.Error(ctx, "failed to reset failed notifications", logger.Int("count", len(ids)), logger.Error(err))
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// SuppressionList looks up addresses that must not be sent to, such as hard
+// bounced or unsubscribed ones.
+type SuppressionList interface {
+	Lookup(ctx context.Context, channel shared.Channel, address string) (*suppression.Suppression, error)
+}
+
+// checkSuppressionList suppresses a notification whose address is on the
+// suppression list and drops suppressed CC and BCC addresses from an email.
+// Unlike preferences the check is not best effort: sending to a bounced
+// address hurts the sender's reputation, so a failed lookup is returned for
+// the caller to retry the notification.
+func (s *serviceImpl) checkSuppressionList(ctx context.Context, n *Notification) (bool, error) {
+	if s.suppressions == nil {
+		return false, nil
+	}
+
+	entry, err := s.lookupSuppression(ctx, n.Channel, n.Recipient.address(n.Channel))
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		if n.Recipient.CC, err = s.withoutSuppressed(ctx, n, n.Recipient.CC); err != nil {
+			return false, err
+		}
+		n.Recipient.BCC, err = s.withoutSuppressed(ctx, n, n.Recipient.BCC)
+		return false, err
+	}
+
+	if err := s.repo.MarkSuppressed(ctx, n.ID, reasonSuppressionList+":"+string(entry.Reason)); err != nil {
+		return false, err
+	}
+
+	s.log.Info(ctx, "notification suppressed by suppression list",
+		logger.Int64("notificationID", n.ID),
+		logger.Int64("suppressionID", entry.ID),
+		logger.String("reason", string(entry.Reason)),
+	)
+	return true, nil
+}
+
+// lookupSuppression returns the active suppression of an address, or nil.
+func (s *serviceImpl) lookupSuppression(ctx context.Context, channel shared.Channel, address string) (*suppression.Suppression, error) {
+	entry, err := s.suppressions.Lookup(ctx, channel, address)
+	if errors.Is(err, shared.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// withoutSuppressed returns the copy addresses of an email that are not on
+// the suppression list. They are only dropped for this delivery.
+func (s *serviceImpl) withoutSuppressed(ctx context.Context, n *Notification, addresses []string) ([]string, error) {
+	var kept []string
+	for _, address := range addresses {
+		entry, err := s.lookupSuppression(ctx, shared.ChannelEmail, address)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			s.log.Info(ctx, "copy address dropped by suppression list",
+				logger.Int64("notificationID", n.ID),
+				logger.Int64("suppressionID", entry.ID),
+				logger.String("reason", string(entry.Reason)),
+			)
+			continue
+		}
+		kept = append(kept, address)
+	}
+	return kept, nil
+}
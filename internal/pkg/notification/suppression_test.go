@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSuppressions suppresses the listed addresses, or fails every lookup.
+type fakeSuppressions struct {
+	addresses map[string]bool
+	err       error
+}
+
+func (f fakeSuppressions) Lookup(_ context.Context, _ shared.Channel, address string) (*suppression.Suppression, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !f.addresses[address] {
+		return nil, shared.ErrRecordNotFound
+	}
+	return &suppression.Suppression{Address: address, Reason: suppression.ReasonHardBounce}, nil
+}
+
+// recipientSender records the recipients of every send.
+type recipientSender struct {
+	fakeSender
+	recipients []NotificationRecipient
+}
+
+func (r *recipientSender) Send(ctx context.Context, n Notification, content renderer.RenderedTemplate) (SendResult, error) {
+	r.recipients = append(r.recipients, n.Recipient)
+	return r.fakeSender.Send(ctx, n, content)
+}
+
+func TestSuppressionListDropsCopyAddresses(t *testing.T) {
+	email := "ada@example.com"
+	n := inAppNotification(1)
+	n.Channel = shared.ChannelEmail
+	n.Recipient = NotificationRecipient{
+		Email: &email,
+		CC:    []string{"kept@example.com", "bounced@example.com"},
+		BCC:   []string{"complained@example.com"},
+	}
+	repo := newFakeRepo(n)
+	sender := &recipientSender{}
+	s := newTestService(repo)
+	s.senders[shared.ChannelEmail] = sender
+	s.suppressions = fakeSuppressions{addresses: map[string]bool{"bounced@example.com": true, "complained@example.com": true}}
+
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Equal(t, StatusSent, repo.statuses[1])
+	require.Len(t, sender.recipients, 1)
+	require.Equal(t, []string{"kept@example.com"}, sender.recipients[0].CC)
+	require.Empty(t, sender.recipients[0].BCC)
+}
+
+func TestSuppressionListLookupFailureRetries(t *testing.T) {
+	repo := newFakeRepo(inAppNotification(1))
+	sender := &fakeSender{}
+	s := newTestService(repo)
+	s.senders[shared.ChannelInApp] = sender
+	s.suppressions = fakeSuppressions{err: errors.New("connection refused")}
+
+	require.NoError(t, s.Process(context.Background(), 1))
+	require.Empty(t, sender.sent)
+	require.Equal(t, StatusScheduled, repo.statuses[1])
+	require.Contains(t, repo.retries, int64(1))
+}
@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/unsubscribe"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// unsubscribeURLKey is the template variable holding the recipient's
+// one-click unsubscribe link.
+const unsubscribeURLKey = "UnsubscribeURL"
+
+// withUnsubscribe returns the template data for rendering n. Emails of a
+// category with unsubscribe links enabled get a signed per-recipient link
+// as UnsubscribeURL and the matching RFC 8058 headers.
+func (s *serviceImpl) withUnsubscribe(n *Notification, category string) (map[string]any, error) {
+	if n.Channel != shared.ChannelEmail || s.unsubscribeCfg == nil || !s.unsubscribeCfg.Enabled(category) {
+		return n.TemplateKeyValue, nil
+	}
+
+	t := unsubscribe.Token{
+		Channel:  n.Channel,
+		Address:  n.Recipient.address(n.Channel),
+		Category: category,
+	}
+	if n.Recipient.UserID != nil {
+		t.UserID = *n.Recipient.UserID
+	}
+
+	token, err := unsubscribe.Sign(s.unsubscribeCfg.Secret, t)
+	if err != nil {
+		return nil, err
+	}
+	link := strings.TrimSuffix(s.unsubscribeCfg.BaseURL, "/") + "/" + token
+
+	// copied so the link never ends up in data persisted from n, e.g. a fallback
+	data := make(map[string]any, len(n.TemplateKeyValue)+1)
+	maps.Copy(data, n.TemplateKeyValue)
+	data[unsubscribeURLKey] = link
+
+	if n.Headers == nil {
+		n.Headers = map[string]string{}
+	}
+	n.Headers["List-Unsubscribe"] = "<" + link + ">"
+	n.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+
+	return data, nil
+}
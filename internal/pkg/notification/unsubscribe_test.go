@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/unsubscribe"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUnsubscribe(t *testing.T) {
+	s := &serviceImpl{unsubscribeCfg: &config.UnsubscribeConfig{
+		Secret:     "secret",
+		BaseURL:    "https://notify.example.com/v1/unsubscribe/",
+		Categories: []string{"marketing"},
+	}}
+	email, userID := "bob@example.com", "u123"
+	n := &Notification{
+		Channel:          shared.ChannelEmail,
+		Recipient:        NotificationRecipient{Email: &email, UserID: &userID},
+		TemplateKeyValue: map[string]any{"UserName": "Bob"},
+	}
+
+	data, err := s.withUnsubscribe(n, "marketing")
+	require.NoError(t, err)
+	require.NotContains(t, n.TemplateKeyValue, unsubscribeURLKey)
+
+	link := data[unsubscribeURLKey].(string)
+	require.Equal(t, "<"+link+">", n.Headers["List-Unsubscribe"])
+	require.Equal(t, "List-Unsubscribe=One-Click", n.Headers["List-Unsubscribe-Post"])
+
+	token, ok := strings.CutPrefix(link, "https://notify.example.com/v1/unsubscribe/")
+	require.True(t, ok)
+	got, err := unsubscribe.Verify("secret", token)
+	require.NoError(t, err)
+	require.Equal(t, unsubscribe.Token{Channel: shared.ChannelEmail, Address: email, UserID: userID, Category: "marketing"}, got)
+
+	// other categories are left alone
+	n = &Notification{Channel: shared.ChannelEmail, Recipient: NotificationRecipient{Email: &email}}
+	data, err = s.withUnsubscribe(n, "transactional")
+	require.NoError(t, err)
+	require.NotContains(t, data, unsubscribeURLKey)
+	require.Empty(t, n.Headers)
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// reservedWebhookHeaders are set by the webhook sender.
+var reservedWebhookHeaders = map[string]bool{
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+	"User-Agent":     true,
+}
+
+// applyWebhookOptions validates the headers of a webhook send request and
+// stores them on the recipient.
+func applyWebhookOptions(channel shared.Channel, opts *WebhookOptions, r *NotificationRecipient) error {
+	if opts == nil {
+		return nil
+	}
+	if channel != shared.ChannelWebhook {
+		return errors.New("webhook options are only supported for the webhook channel")
+	}
+
+	for name, value := range opts.Headers {
+		canonical := textproto.CanonicalMIMEHeaderKey(name)
+		if !validHeaderName(name) || reservedWebhookHeaders[canonical] || strings.HasPrefix(canonical, "X-Notify-") {
+			return errors.New("invalid or reserved header " + name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("invalid value for header " + name)
+		}
+	}
+	if len(opts.Headers) > 0 {
+		r.Headers = opts.Headers
+	}
+	return nil
+}
+
+// validHeaderName accepts names made of RFC 7230 token characters.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c <= ' ' || c > '~' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
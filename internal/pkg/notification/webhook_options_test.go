@@ -0,0 +1,27 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWebhookOptions(t *testing.T) {
+	var r NotificationRecipient
+	require.NoError(t, applyWebhookOptions(shared.ChannelWebhook, &WebhookOptions{
+		Headers: map[string]string{"Authorization": "Bearer abc"},
+	}, &r))
+	require.Equal(t, "Bearer abc", r.Headers["Authorization"])
+
+	for _, headers := range []map[string]string{
+		{"content-type": "text/html"},
+		{"X-Notify-Signature": "forged"},
+		{"Bad Name": "x"},
+		{"X-Tag": "a\r\nHost: evil"},
+	} {
+		require.Error(t, applyWebhookOptions(shared.ChannelWebhook, &WebhookOptions{Headers: headers}, &r), headers)
+	}
+
+	require.Error(t, applyWebhookOptions(shared.ChannelEmail, &WebhookOptions{}, &r))
+}
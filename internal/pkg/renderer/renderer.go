@@ -2,17 +2,29 @@ package renderer
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"text/template"
 )
 
 type RenderedTemplate struct {
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	HTMLBody string `json:"html_body,omitempty"`
+	// Blocks is a Slack Block Kit layout as a JSON array.
+	Blocks string `json:"blocks,omitempty"`
+	// SMS is set for SMS templates.
+	SMS *SMSInfo `json:"sms,omitempty"`
 }
 
 type Renderer interface {
 	Render(subject, body string, data map[string]any) (RenderedTemplate, error)
+	// RenderHTML renders an HTML body, escaping the data for its HTML context.
+	RenderHTML(body string, data map[string]any) (string, error)
+	// RenderJSON renders a JSON document, escaping string data so it can be
+	// placed within JSON string literals.
+	RenderJSON(body string, data map[string]any) (string, error)
 }
 
 type GoTemplateRenderer struct {
@@ -45,6 +57,53 @@ func (r *GoTemplateRenderer) Render(subject, body string, data map[string]any) (
 	return result, nil
 }
 
+func (r *GoTemplateRenderer) RenderHTML(body string, data map[string]any) (string, error) {
+	t, err := htmltemplate.New("tpl").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("render html body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render html body: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func (r *GoTemplateRenderer) RenderJSON(body string, data map[string]any) (string, error) {
+	escaped, _ := escapeJSON(data).(map[string]any)
+	out, err := renderString(body, escaped)
+	if err != nil {
+		return "", fmt.Errorf("render json: %w", err)
+	}
+	return out, nil
+}
+
+// escapeJSON copies v with every string escaped as the inside of a JSON
+// string literal, so data cannot break out of the quotes around it.
+func escapeJSON(v any) any {
+	switch v := v.(type) {
+	case string:
+		b, _ := json.Marshal(v)
+		return string(b[1 : len(b)-1])
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = escapeJSON(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = escapeJSON(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 func renderString(tpl string, data map[string]any) (string, error) {
 
 	t, err := template.New("tpl").Option("missingkey=error").Parse(tpl)
@@ -21,3 +21,32 @@ func TestRenderTemplate(t *testing.T) {
 	require.Equal(t, "Hello User", out.Subject)
 	require.Equal(t, "Welcome to NotifyX", out.Body)
 }
+
+func TestRenderHTMLEscapesData(t *testing.T) {
+	r := NewGoTemplateRenderer()
+
+	out, err := r.RenderHTML(`<p>Hi {{.Name}}</p>`, map[string]any{"Name": "<b>Bob</b>"})
+
+	require.NoError(t, err)
+	require.Equal(t, "<p>Hi &lt;b&gt;Bob&lt;/b&gt;</p>", out)
+}
+
+func TestRenderJSONEscapesData(t *testing.T) {
+	r := NewGoTemplateRenderer()
+
+	out, err := r.RenderJSON(`{"text": "Hi {{.Name}}", "count": {{.Count}}}`, map[string]any{
+		"Name":  "Bob \"the\" \\ Builder\n",
+		"Count": 3,
+	})
+
+	require.NoError(t, err)
+	require.JSONEq(t, `{"text": "Hi Bob \"the\" \\ Builder\n", "count": 3}`, out)
+}
+
+func TestHTMLToText(t *testing.T) {
+	body := `<html><head><title>Hi</title><style>p{color:red}</style></head>
+<body><h1>Welcome,   Bob</h1><p>Thanks for joining.<br>See <a href="https://example.com/start">the guide</a>.</p>
+<ul><li>One</li><li>Two &amp; three</li></ul></body></html>`
+
+	require.Equal(t, "Welcome, Bob\n\nThanks for joining.\nSee the guide (https://example.com/start).\n\n- One\n- Two & three", HTMLToText(body))
+}
@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// SMS encodings. A body is sent as GSM-7 when every character is in the GSM
+// 03.38 alphabet, otherwise as UCS-2.
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+)
+
+// SMSInfo describes how an SMS body is sent. Units are GSM-7 septets or
+// UTF-16 code units; a body longer than one segment is split into
+// concatenated segments that each lose room to the concatenation header.
+type SMSInfo struct {
+	Encoding string `json:"encoding"`
+	Units    int    `json:"units"`
+	Segments int    `json:"segments"`
+}
+
+// gsm7Basic is the GSM 03.38 default alphabet, one septet each. The escape
+// character is left out, it only introduces extension characters.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension characters take an escape septet and one of their own.
+const gsm7Extension = "^{}\\[~]|€\f"
+
+// Segment sizes in units, for a single and a concatenated message.
+var segmentSizes = map[string][2]int{
+	EncodingGSM7: {160, 153},
+	EncodingUCS2: {70, 67},
+}
+
+// CountSMS returns the encoding, length and segment count of body. Segments
+// are filled greedily, as handsets do, so an escaped character or surrogate
+// pair never straddles two segments.
+func CountSMS(body string) SMSInfo {
+	encoding := EncodingGSM7
+	for _, r := range body {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			encoding = EncodingUCS2
+			break
+		}
+	}
+
+	widths := make([]int, 0, len(body))
+	units := 0
+	for _, r := range body {
+		w := 1
+		switch {
+		case encoding == EncodingGSM7 && strings.ContainsRune(gsm7Extension, r):
+			w = 2
+		case encoding == EncodingUCS2:
+			w = utf16.RuneLen(r)
+		}
+		widths = append(widths, w)
+		units += w
+	}
+
+	info := SMSInfo{Encoding: encoding, Units: units}
+	size := segmentSizes[encoding]
+	switch {
+	case units == 0:
+	case units <= size[0]:
+		info.Segments = 1
+	default:
+		used := 0
+		info.Segments = 1
+		for _, w := range widths {
+			if used+w > size[1] {
+				info.Segments++
+				used = 0
+			}
+			used += w
+		}
+	}
+	return info
+}
@@ -0,0 +1,30 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountSMS(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want SMSInfo
+	}{
+		{"empty", "", SMSInfo{Encoding: EncodingGSM7}},
+		{"single gsm", strings.Repeat("a", 160), SMSInfo{EncodingGSM7, 160, 1}},
+		{"concatenated gsm", strings.Repeat("a", 161), SMSInfo{EncodingGSM7, 161, 2}},
+		{"extension counts twice", strings.Repeat("€", 80), SMSInfo{EncodingGSM7, 160, 1}},
+		{"extension not split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), SMSInfo{EncodingGSM7, 164, 2}},
+		{"ucs2", "Grüße " + strings.Repeat("ж", 64), SMSInfo{EncodingUCS2, 70, 1}},
+		{"surrogate pairs", strings.Repeat("😀", 35), SMSInfo{EncodingUCS2, 70, 1}},
+		{"concatenated ucs2", strings.Repeat("😀", 36), SMSInfo{EncodingUCS2, 72, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, CountSMS(tt.body))
+		})
+	}
+}
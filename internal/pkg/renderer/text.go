@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	spaces     = regexp.MustCompile(`[ \t\r\f]+`)
+)
+
+// HTMLToText derives a plain-text alternative from an HTML email body: block
+// elements become line breaks, links keep their target, and scripts, styles
+// and the head are dropped.
+func HTMLToText(body string) string {
+	var (
+		b    strings.Builder
+		skip int
+		href []string
+	)
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, or malformed input past which nothing more can be read
+			break
+		}
+
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(spaces.ReplaceAllString(strings.ReplaceAll(tok.Data, "\n", " "), " "))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.Data {
+			case "script", "style", "head", "title":
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case "br":
+				b.WriteString("\n")
+			case "li":
+				b.WriteString("\n- ")
+			case "p", "div", "tr", "table", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "hr":
+				b.WriteString("\n\n")
+			case "a":
+				link := ""
+				for _, attr := range tok.Attr {
+					if attr.Key == "href" && !strings.HasPrefix(attr.Val, "#") {
+						link = attr.Val
+					}
+				}
+				href = append(href, link)
+			}
+
+		case html.EndTagToken:
+			switch tok.Data {
+			case "script", "style", "head", "title":
+				if skip > 0 {
+					skip--
+				}
+			case "p", "div", "tr", "table", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote":
+				b.WriteString("\n\n")
+			case "a":
+				if n := len(href); n > 0 {
+					if link := href[n-1]; link != "" {
+						b.WriteString(" (" + link + ")")
+					}
+					href = href[:n-1]
+				}
+			}
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,132 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+)
+
+// TLS modes of SMTPConfig.TLS. The empty mode upgrades with STARTTLS when
+// the server offers it and carries on in plain text otherwise.
+const (
+	TLSNone     = "none"
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+)
+
+var errNoStartTLS = errors.New("smtp server does not support STARTTLS")
+
+type dialer struct {
+	addr    string
+	host    string
+	mode    string
+	tls     *tls.Config
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func newDialer(cfg *config.SMTPConfig) (*dialer, error) {
+	switch cfg.TLS {
+	case "", TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("invalid smtp tls mode %q, expected none, starttls or tls", cfg.TLS)
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read smtp ca file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in smtp ca file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	var auth smtp.Auth
+	if cfg.User != "" {
+		auth = smtp.PlainAuth("", cfg.User, cfg.Pass, cfg.Host)
+	}
+
+	return &dialer{
+		addr:    net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:    cfg.Host,
+		mode:    cfg.TLS,
+		tls:     tlsConfig,
+		auth:    auth,
+		timeout: cfg.DialTimeout,
+	}, nil
+}
+
+// dial connects, secures and authenticates a new SMTP connection.
+func (d *dialer) dial(ctx context.Context) (*conn, error) {
+	nd := net.Dialer{Timeout: d.timeout}
+	raw, err := nd.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := d.handshake(ctx, raw)
+	if err != nil {
+		_ = raw.Close()
+		return nil, err
+	}
+	_ = raw.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (d *dialer) handshake(ctx context.Context, raw net.Conn) (*conn, error) {
+	c := &conn{raw: raw}
+	stop := c.bind(ctx, d.timeout)
+	defer stop()
+
+	var nc net.Conn = raw
+	if d.mode == TLSImplicit {
+		tc := tls.Client(raw, d.tls)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		nc = tc
+	}
+
+	client, err := smtp.NewClient(nc, d.host)
+	if err != nil {
+		return nil, err
+	}
+	c.client = client
+
+	if d.mode == "" || d.mode == TLSStartTLS {
+		ok, _ := client.Extension("STARTTLS")
+		switch {
+		case ok:
+			if err := client.StartTLS(d.tls); err != nil {
+				return nil, err
+			}
+		case d.mode == TLSStartTLS:
+			return nil, errNoStartTLS
+		}
+	}
+
+	if d.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return nil, errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(d.auth); err != nil {
+			return nil, err
+		}
+	}
+
+	c.lastUsed = time.Now()
+	return c, nil
+}
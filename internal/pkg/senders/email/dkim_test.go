@@ -0,0 +1,97 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/emersion/go-msgauth/dkim"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDKIMSignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPub, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		key    crypto.Signer
+		record string
+	}{
+		"rsa-sha256": {rsaKey, "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(rsaPub)},
+		"ed25519":    {edKey, "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(edPub)},
+	}
+
+	for algo, tc := range cases {
+		t.Run(algo, func(t *testing.T) {
+			signer, err := newDKIMSigner(config.DKIMConfig{
+				Domain:         "notify.local",
+				Selector:       "s1",
+				PrivateKeyPath: writeKey(t, tc.key),
+			})
+			require.NoError(t, err)
+
+			msg, err := Message{
+				From:      "no-reply@notify.local",
+				To:        []string{"bob@example.com"},
+				Subject:   "Hello",
+				Text:      "Hello Bob",
+				HTML:      "<p>Hello Bob</p>",
+				Date:      time.Now(),
+				MessageID: "<1.abc@notify.local>",
+			}.Bytes()
+			require.NoError(t, err)
+
+			signed, err := signer.sign(msg)
+			require.NoError(t, err)
+
+			lookup := func(domain string) ([]string, error) {
+				require.Equal(t, "s1._domainkey.notify.local", domain)
+				return []string{tc.record}, nil
+			}
+
+			verifications, err := dkim.VerifyWithOptions(bytes.NewReader(signed), &dkim.VerifyOptions{LookupTXT: lookup})
+			require.NoError(t, err)
+			require.Len(t, verifications, 1)
+			require.NoError(t, verifications[0].Err)
+			require.Equal(t, "notify.local", verifications[0].Domain)
+
+			// any change to the body breaks the signature
+			tampered := bytes.Replace(signed, []byte("Hello Bob"), []byte("Hello Eve"), 1)
+			verifications, err = dkim.VerifyWithOptions(bytes.NewReader(tampered), &dkim.VerifyOptions{LookupTXT: lookup})
+			require.NoError(t, err)
+			require.Error(t, verifications[0].Err)
+		})
+	}
+}
+
+func TestDKIMDisabledWithoutDomain(t *testing.T) {
+	signer, err := newDKIMSigner(config.DKIMConfig{})
+	require.NoError(t, err)
+	require.Nil(t, signer)
+}
+
+func writeKey(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "dkim.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
@@ -0,0 +1,231 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// reservedHeaders are written by the builder itself and cannot be set
+// through Message.Headers.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Reply-To":                  true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+	"Content-Disposition":       true,
+	"Content-Id":                true,
+}
+
+// Message is an outgoing email. Without HTML it is sent as text/plain,
+// otherwise as multipart/alternative with the text as fallback. Inline
+// attachments wrap the HTML in multipart/related, other attachments wrap
+// everything in multipart/mixed.
+// Bcc recipients have no field here, they are only given to the SMTP
+// envelope.
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Headers     map[string]string
+	Date        time.Time
+	MessageID   string
+}
+
+// Attachment is a file sent with a Message. Inline attachments are shown
+// within the HTML body, which refers to them as cid:<ContentID>.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Inline      bool
+	Content     []byte
+}
+
+// part is a MIME entity: its headers and a function writing its encoded body.
+type part struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer) error
+}
+
+// Bytes renders the message in RFC 5322 form with CRLF line endings. Text
+// is quoted-printable and attachments base64 encoded, so lines stay within
+// SMTP limits whatever the content.
+func (m Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeHeader(&buf, "From", m.From)
+	writeHeader(&buf, "To", strings.Join(m.To, ", "))
+	if len(m.Cc) > 0 {
+		writeHeader(&buf, "Cc", strings.Join(m.Cc, ", "))
+	}
+	if m.ReplyTo != "" {
+		writeHeader(&buf, "Reply-To", m.ReplyTo)
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "Date", m.Date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", m.MessageID)
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	for _, name := range slices.Sorted(maps.Keys(m.Headers)) {
+		value := m.Headers[name]
+		// a line break in either would inject further headers
+		if strings.ContainsAny(name, "\r\n: ") || strings.ContainsAny(value, "\r\n") {
+			continue
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			continue
+		}
+		writeHeader(&buf, name, value)
+	}
+
+	root := m.root()
+	for _, name := range slices.Sorted(maps.Keys(root.header)) {
+		writeHeader(&buf, name, root.header.Get(name))
+	}
+	buf.WriteString("\r\n")
+
+	if err := root.write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (m Message) root() part {
+	var inline, attached []part
+	for _, a := range m.Attachments {
+		// inline parts only make sense next to an HTML body
+		if a.Inline && m.HTML != "" {
+			inline = append(inline, attachmentPart(a, "inline"))
+		} else {
+			attached = append(attached, attachmentPart(a, "attachment"))
+		}
+	}
+
+	body := textPart("text/plain", m.Text)
+	if m.HTML != "" {
+		html := textPart("text/html", m.HTML)
+		if len(inline) > 0 {
+			html = multipartPart("related", append([]part{html}, inline...)...)
+		}
+		// least preferred first, as RFC 2046 requires
+		body = multipartPart("alternative", body, html)
+	}
+
+	if len(attached) > 0 {
+		body = multipartPart("mixed", append([]part{body}, attached...)...)
+	}
+	return body
+}
+
+func textPart(mediaType, body string) part {
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {mediaType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		write: func(w io.Writer) error {
+			qp := quotedprintable.NewWriter(w)
+			if _, err := qp.Write([]byte(body)); err != nil {
+				return err
+			}
+			return qp.Close()
+		},
+	}
+}
+
+func attachmentPart(a Attachment, disposition string) part {
+	contentType := mime.FormatMediaType(a.ContentType, map[string]string{"name": a.Filename})
+	if contentType == "" {
+		contentType = mime.FormatMediaType("application/octet-stream", map[string]string{"name": a.Filename})
+	}
+
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+	}
+	if a.ContentID != "" {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+
+	return part{
+		header: header,
+		write: func(w io.Writer) error {
+			encoded := base64.StdEncoding.EncodeToString(a.Content)
+			// RFC 2045 limits encoded lines to 76 characters
+			for len(encoded) > 76 {
+				if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+					return err
+				}
+				encoded = encoded[76:]
+			}
+			_, err := io.WriteString(w, encoded)
+			return err
+		},
+	}
+}
+
+func multipartPart(subtype string, children ...part) part {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type": {"multipart/" + subtype + "; boundary=" + boundary},
+		},
+		write: func(w io.Writer) error {
+			mw := multipart.NewWriter(w)
+			if err := mw.SetBoundary(boundary); err != nil {
+				return err
+			}
+			for _, child := range children {
+				pw, err := mw.CreatePart(child.header)
+				if err != nil {
+					return err
+				}
+				if err := child.write(pw); err != nil {
+					return err
+				}
+			}
+			return mw.Close()
+		},
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name + ": " + value + "\r\n")
+}
+
+// newMessageID returns a globally unique Message-ID in the domain of from.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.TrimSuffix(from[at+1:], ">")
+	}
+
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,187 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagePlainText(t *testing.T) {
+	raw, err := Message{
+		From:      "no-reply@notify.local",
+		To:        []string{"bob@example.com"},
+		Subject:   "Grüße",
+		Text:      "Hello Bob",
+		Date:      time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC),
+		MessageID: "<1.abc@notify.local>",
+		Headers: map[string]string{
+			"List-Unsubscribe": "<https://notify.local/u/t>",
+			"Subject":          "overridden",
+			"X-Bad":            "a\r\nBcc: eve@example.com",
+		},
+	}.Bytes()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Grüße", subject)
+	require.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+	require.Equal(t, "<1.abc@notify.local>", msg.Header.Get("Message-ID"))
+	require.Equal(t, "Wed, 01 Jul 2026 09:00:00 +0000", msg.Header.Get("Date"))
+	require.Equal(t, "<https://notify.local/u/t>", msg.Header.Get("List-Unsubscribe"))
+	require.Len(t, msg.Header["Subject"], 1)
+	require.Empty(t, msg.Header.Get("X-Bad"))
+	require.Empty(t, msg.Header.Get("Bcc"))
+
+	mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", mediaType)
+}
+
+func TestMessageAlternative(t *testing.T) {
+	raw, err := Message{
+		From:      "no-reply@notify.local",
+		To:        []string{"bob@example.com"},
+		Subject:   "Welcome",
+		Text:      "Hello Bob",
+		HTML:      "<p>Hello <b>Bob</b></p>",
+		Date:      time.Now(),
+		MessageID: newMessageID("Notify <no-reply@notify.local>"),
+	}.Bytes()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	require.Regexp(t, `^<\d+\.[0-9a-f]{24}@notify\.local>$`, msg.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	// the reader decodes quoted-printable parts transparently
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var got []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		got = append(got, part.Header.Get("Content-Type")+" "+string(body))
+	}
+	require.Equal(t, []string{
+		`text/plain; charset="utf-8" Hello Bob`,
+		`text/html; charset="utf-8" <p>Hello <b>Bob</b></p>`,
+	}, got)
+}
+
+func TestMessageAttachments(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.7 "), 20)
+	raw, err := Message{
+		From:      "no-reply@notify.local",
+		To:        []string{"bob@example.com"},
+		Subject:   "Your invoice",
+		Text:      "Invoice attached",
+		HTML:      `<img src="cid:logo"><p>Invoice attached</p>`,
+		Date:      time.Now(),
+		MessageID: "<1.abc@notify.local>",
+		Attachments: []Attachment{
+			{Filename: "logo.png", ContentType: "image/png", ContentID: "logo", Inline: true, Content: []byte("png")},
+			{Filename: "invoice.pdf", ContentType: "application/pdf", Content: pdf},
+		},
+	}.Bytes()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	// mixed[alternative[text, related[html, logo]], invoice]
+	mixed := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	require.Len(t, mixed, 2)
+	require.Equal(t, `attachment; filename=invoice.pdf`, mixed[1].header.Get("Content-Disposition"))
+	require.Equal(t, `application/pdf; name=invoice.pdf`, mixed[1].header.Get("Content-Type"))
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(mixed[1].body), "\r\n", ""))
+	require.NoError(t, err)
+	require.Equal(t, pdf, decoded)
+	for _, line := range strings.Split(string(mixed[1].body), "\r\n") {
+		require.LessOrEqual(t, len(line), 76)
+	}
+
+	alternative := readParts(t, mixed[0].header.Get("Content-Type"), bytes.NewReader(mixed[0].body))
+	require.Len(t, alternative, 2)
+	require.Equal(t, "Invoice attached", string(alternative[0].body))
+
+	related := readParts(t, alternative[1].header.Get("Content-Type"), bytes.NewReader(alternative[1].body))
+	require.Len(t, related, 2)
+	require.Equal(t, `<img src="cid:logo"><p>Invoice attached</p>`, string(related[0].body))
+	require.Equal(t, "<logo>", related[1].header.Get("Content-ID"))
+	require.Equal(t, "inline; filename=logo.png", related[1].header.Get("Content-Disposition"))
+}
+
+type rawPart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+func readParts(t *testing.T, contentType string, body io.Reader) []rawPart {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(mediaType, "multipart/"), mediaType)
+
+	var parts []rawPart
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(p)
+		require.NoError(t, err)
+		parts = append(parts, rawPart{header: p.Header, body: b})
+	}
+}
+
+func TestMessageCcAndReplyTo(t *testing.T) {
+	raw, err := Message{
+		From:      `"Billing" <billing@notify.local>`,
+		To:        []string{"bob@example.com"},
+		Cc:        []string{"carol@example.com", "dave@example.com"},
+		ReplyTo:   "<support@example.com>",
+		Subject:   "Invoice",
+		Text:      "Hello Bob",
+		Date:      time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC),
+		MessageID: "<1.abc@notify.local>",
+		Headers: map[string]string{
+			"X-Campaign": "spring",
+			"Reply-To":   "eve@example.com",
+			"Bcc":        "eve@example.com",
+		},
+	}.Bytes()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	require.Equal(t, `"Billing" <billing@notify.local>`, msg.Header.Get("From"))
+	require.Equal(t, "carol@example.com, dave@example.com", msg.Header.Get("Cc"))
+	require.Equal(t, "<support@example.com>", msg.Header.Get("Reply-To"))
+	require.Equal(t, "spring", msg.Header.Get("X-Campaign"))
+	require.Empty(t, msg.Header.Get("Bcc"))
+}
@@ -0,0 +1,188 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// conn is an authenticated SMTP connection. raw is the underlying TCP
+// connection, deadlines set on it also bound a TLS session on top.
+type conn struct {
+	client   *smtp.Client
+	raw      net.Conn
+	lastUsed time.Time
+}
+
+// send runs one mail transaction. It gives up at the earlier of timeout and
+// the context deadline, and as soon as ctx is cancelled.
+func (c *conn) send(ctx context.Context, timeout time.Duration, from string, to []string, msg []byte) error {
+	stop := c.bind(ctx, timeout)
+	defer stop()
+
+	err := c.transact(from, to, msg)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (c *conn) transact(from string, to []string, msg []byte) error {
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// bind sets the connection deadline from timeout and ctx and interrupts any
+// pending I/O once ctx is done. The returned func releases ctx.
+func (c *conn) bind(ctx context.Context, timeout time.Duration) func() bool {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	_ = c.raw.SetDeadline(deadline)
+
+	return context.AfterFunc(ctx, func() {
+		_ = c.raw.SetDeadline(time.Unix(1, 0))
+	})
+}
+
+func (c *conn) close() {
+	// QUIT is a courtesy, the server may already have dropped us
+	_ = c.raw.SetDeadline(time.Now().Add(time.Second))
+	if err := c.client.Quit(); err != nil {
+		_ = c.client.Close()
+	}
+}
+
+// pool reuses SMTP connections across sends and bounds how many are open at
+// once. Idle connections are checked with NOOP before reuse and dropped once
+// idle for longer than idleTimeout.
+type pool struct {
+	dial         func(ctx context.Context) (*conn, error)
+	idleTimeout  time.Duration
+	checkTimeout time.Duration
+	slots        chan struct{}
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func newPool(size int, idleTimeout, checkTimeout time.Duration, dial func(ctx context.Context) (*conn, error)) *pool {
+	if size < 1 {
+		size = 1
+	}
+	return &pool{
+		dial:         dial,
+		idleTimeout:  idleTimeout,
+		checkTimeout: checkTimeout,
+		slots:        make(chan struct{}, size),
+	}
+}
+
+// get returns an idle connection that still answers or dials a new one,
+// waiting for a free slot while the pool is exhausted. Every successful get
+// must be followed by put.
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for c := p.popIdle(); c != nil; c = p.popIdle() {
+		if p.idleTimeout > 0 && time.Since(c.lastUsed) > p.idleTimeout {
+			c.close()
+			continue
+		}
+
+		stop := c.bind(ctx, p.checkTimeout)
+		err := c.client.Noop()
+		stop()
+		if err != nil {
+			_ = c.client.Close()
+			continue
+		}
+		return c, nil
+	}
+
+	c, err := p.dial(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+// put hands c back after a send. A connection whose send failed is closed
+// rather than reused, as its state is unknown.
+func (p *pool) put(c *conn, sendErr error) {
+	defer func() { <-p.slots }()
+
+	if sendErr != nil {
+		_ = c.client.Close()
+		return
+	}
+
+	stop := c.bind(context.Background(), p.checkTimeout)
+	err := c.client.Reset()
+	stop()
+	if err != nil {
+		_ = c.client.Close()
+		return
+	}
+	_ = c.raw.SetDeadline(time.Time{})
+	c.lastUsed = time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		c.close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+// popIdle takes the most recently used idle connection.
+func (p *pool) popIdle() *conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.idle) == 0 {
+		return nil
+	}
+	c := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return c
+}
+
+// close quits the idle connections; connections in use are closed when put.
+func (p *pool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.close()
+	}
+}
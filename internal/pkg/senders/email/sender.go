@@ -2,65 +2,181 @@ package email
 
 import (
 	"context"
-	"fmt"
-	"net/smtp"
+	"errors"
+	"maps"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
 
+	"github.com/ckshitij/notify-srv/internal/config"
 	"github.com/ckshitij/notify-srv/internal/pkg/notification"
 	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
 type Sender struct {
-	host string
-	port int
-	from string
-	auth smtp.Auth
+	addr        string
+	from        string
+	sendTimeout time.Duration
+	pool        *pool
+	dkim        *dkimSigner
+
+	// allowedFrom holds the lowercased addresses a notification may use as
+	// From, the default sender included.
+	allowedFrom map[string]bool
 }
 
-func New(
-	host string,
-	port int,
-	from string,
-	username string,
-	password string,
-) *Sender {
+func New(cfg *config.SMTPConfig) (*Sender, error) {
+
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := newDKIMSigner(cfg.DKIM)
+	if err != nil {
+		return nil, err
+	}
 
-	var auth smtp.Auth
-	if username != "" {
-		auth = smtp.PlainAuth("", username, password, host)
+	allowed := map[string]bool{strings.ToLower(addressOf(cfg.From)): true}
+	for _, a := range cfg.AllowedFrom {
+		allowed[strings.ToLower(addressOf(a))] = true
 	}
 
 	return &Sender{
-		host: host,
-		port: port,
-		from: from,
-		auth: auth,
+		addr:        dialer.addr,
+		from:        cfg.From,
+		sendTimeout: cfg.SendTimeout,
+		pool:        newPool(cfg.PoolSize, cfg.IdleTimeout, cfg.DialTimeout, dialer.dial),
+		dkim:        signer,
+		allowedFrom: allowed,
+	}, nil
+}
+
+// Close quits the idle SMTP connections.
+func (s *Sender) Close() {
+	s.pool.close()
+}
+
+// Validate rejects a From override whose address is not a configured sender
+// identity. The display name is free.
+func (s *Sender) Validate(n notification.Notification) error {
+	if n.Recipient.From == nil {
+		return nil
 	}
+	if !s.allowedFrom[strings.ToLower(addressOf(*n.Recipient.From))] {
+		return shared.ErrSenderNotAllowed
+	}
+	return nil
 }
 
 func (s *Sender) Send(
 	ctx context.Context,
 	n notification.Notification,
 	content renderer.RenderedTemplate,
-) error {
+) (notification.SendResult, error) {
 
 	if n.Recipient.Email == nil {
-		return fmt.Errorf("email recipient missing")
+		return notification.SendResult{}, notification.Permanent(errors.New("email recipient missing"))
+	}
+
+	attachments := make([]Attachment, 0, len(n.Attachments))
+	for _, a := range n.Attachments {
+		attachments = append(attachments, Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			ContentID:   a.ContentID,
+			Inline:      a.Inline,
+			Content:     a.Content,
+		})
+	}
+
+	if err := s.Validate(n); err != nil {
+		return notification.SendResult{}, notification.Permanent(err)
 	}
 
-	msg := fmt.Appendf(nil,
-		"To: %s\r\nSubject: %s\r\n\r\n%s",
-		*n.Recipient.Email,
-		content.Subject,
-		content.Body,
-	)
+	from := s.from
+	if n.Recipient.From != nil {
+		from = *n.Recipient.From
+	}
 
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	var replyTo string
+	if n.Recipient.ReplyTo != nil {
+		replyTo = *n.Recipient.ReplyTo
+	}
 
-	return smtp.SendMail(
-		addr,
-		s.auth,
-		s.from,
-		[]string{*n.Recipient.Email},
-		msg,
-	)
+	// recipient headers are set per request, the service ones win
+	headers := maps.Clone(n.Recipient.Headers)
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	maps.Copy(headers, n.Headers)
+
+	messageID := newMessageID(from)
+	msg, err := Message{
+		From:        from,
+		To:          []string{*n.Recipient.Email},
+		Cc:          n.Recipient.CC,
+		ReplyTo:     replyTo,
+		Subject:     content.Subject,
+		Text:        content.Body,
+		HTML:        content.HTMLBody,
+		Attachments: attachments,
+		Headers:     headers,
+		Date:        time.Now(),
+		MessageID:   messageID,
+	}.Bytes()
+	if err != nil {
+		return notification.SendResult{}, err
+	}
+
+	if s.dkim != nil {
+		if msg, err = s.dkim.sign(msg); err != nil {
+			return notification.SendResult{}, err
+		}
+	}
+
+	// Bcc addresses only appear in the envelope
+	rcpts := make([]string, 0, 1+len(n.Recipient.CC)+len(n.Recipient.BCC))
+	rcpts = append(rcpts, *n.Recipient.Email)
+	rcpts = append(rcpts, n.Recipient.CC...)
+	rcpts = append(rcpts, n.Recipient.BCC...)
+
+	c, err := s.pool.get(ctx)
+	if err != nil {
+		return notification.SendResult{}, err
+	}
+
+	// the envelope sender stays the configured one so bounces come back to us
+	err = c.send(ctx, s.sendTimeout, addressOf(s.from), rcpts, msg)
+	s.pool.put(c, err)
+	if err != nil {
+		return notification.SendResult{}, classify(err)
+	}
+
+	return notification.SendResult{
+		ProviderResponse:  "accepted by " + s.addr + " as " + messageID,
+		ProviderMessageID: messageID,
+	}, nil
+}
+
+// classify marks permanent SMTP failures, the 5xx replies, as such so they are
+// not retried; 4xx replies and connection errors stay retryable.
+func classify(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return notification.Permanent(err)
+	}
+	return err
+}
+
+// addressOf returns the bare address of an RFC 5322 address, which may carry
+// a display name, or v itself if it does not parse.
+func addressOf(v string) string {
+	addr, err := mail.ParseAddress(v)
+	if err != nil {
+		return v
+	}
+	return addr.Address
 }
@@ -0,0 +1,192 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderValidateFrom(t *testing.T) {
+	s, err := New(&config.SMTPConfig{
+		Host:        "localhost",
+		Port:        25,
+		From:        "no-reply@notify.local",
+		AllowedFrom: []string{"Billing@notify.local"},
+	})
+	require.NoError(t, err)
+
+	from := func(v string) notification.Notification {
+		return notification.Notification{Recipient: notification.NotificationRecipient{From: &v}}
+	}
+
+	require.NoError(t, s.Validate(notification.Notification{}))
+	require.NoError(t, s.Validate(from(`"Notify" <no-reply@notify.local>`)))
+	require.NoError(t, s.Validate(from(`"Billing" <billing@notify.local>`)))
+	require.ErrorIs(t, s.Validate(from("ceo@notify.local")), shared.ErrSenderNotAllowed)
+}
+
+func TestNewRejectsUnknownTLSMode(t *testing.T) {
+	_, err := New(&config.SMTPConfig{Host: "localhost", TLS: "ssl"})
+	require.Error(t, err)
+}
+
+func TestSenderReusesConnections(t *testing.T) {
+	host, port, conns := fakeSMTPServer(t)
+
+	s, err := New(&config.SMTPConfig{
+		Host:        host,
+		Port:        port,
+		From:        "no-reply@notify.local",
+		TLS:         TLSNone,
+		DialTimeout: time.Second,
+		SendTimeout: time.Second,
+		PoolSize:    2,
+		IdleTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	email := "bob@example.com"
+	n := notification.Notification{Recipient: notification.NotificationRecipient{Email: &email}}
+	for range 3 {
+		_, err := s.Send(context.Background(), n, renderer.RenderedTemplate{Subject: "Hi", Body: "Hello"})
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 1, conns.Load())
+}
+
+func TestSenderRequiresStartTLS(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t)
+
+	s, err := New(&config.SMTPConfig{Host: host, Port: port, From: "no-reply@notify.local", TLS: TLSStartTLS})
+	require.NoError(t, err)
+
+	email := "bob@example.com"
+	n := notification.Notification{Recipient: notification.NotificationRecipient{Email: &email}}
+	_, err = s.Send(context.Background(), n, renderer.RenderedTemplate{Subject: "Hi", Body: "Hello"})
+	require.ErrorIs(t, err, errNoStartTLS)
+}
+
+func TestSenderClassifiesErrors(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t)
+
+	s, err := New(&config.SMTPConfig{
+		Host:        host,
+		Port:        port,
+		From:        "no-reply@notify.local",
+		TLS:         TLSNone,
+		DialTimeout: time.Second,
+		SendTimeout: time.Second,
+		PoolSize:    1,
+		IdleTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	send := func(n notification.Notification) error {
+		_, err := s.Send(context.Background(), n, renderer.RenderedTemplate{Subject: "Hi", Body: "Hello"})
+		return err
+	}
+	to := func(email string) notification.Notification {
+		return notification.Notification{Recipient: notification.NotificationRecipient{Email: &email}}
+	}
+
+	require.True(t, isPermanent(send(to("nobody@example.com"))))
+	require.True(t, isPermanent(send(notification.Notification{})))
+
+	from := "ceo@notify.local"
+	n := to("bob@example.com")
+	n.Recipient.From = &from
+	err = send(n)
+	require.ErrorIs(t, err, shared.ErrSenderNotAllowed)
+	require.True(t, isPermanent(err))
+
+	err = send(to("busy@example.com"))
+	require.Error(t, err)
+	require.False(t, isPermanent(err))
+}
+
+func isPermanent(err error) bool {
+	var p interface{ Permanent() bool }
+	return errors.As(err, &p) && p.Permanent()
+}
+
+// fakeSMTPServer accepts every transaction, except recipients at nobody@ (550)
+// and busy@ (451), without offering STARTTLS, and counts the connections made.
+func fakeSMTPServer(t *testing.T) (string, int, *atomic.Int32) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var conns atomic.Int32
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			go serveSMTP(c)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, &conns
+}
+
+func serveSMTP(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	reply := func(s string) { c.Write([]byte(s + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch verb {
+		case "EHLO":
+			reply("250 fake")
+		case "RCPT":
+			switch {
+			case strings.Contains(line, "<nobody@"):
+				reply("550 no such user")
+			case strings.Contains(line, "<busy@"):
+				reply("451 try again later")
+			default:
+				reply("250 ok")
+			}
+		case "DATA":
+			reply("354 go ahead")
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+			}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -21,13 +21,13 @@ func (s *Sender) Send(
 	ctx context.Context,
 	n notification.Notification,
 	content renderer.RenderedTemplate,
-) error {
+) (notification.SendResult, error) {
 
 	if n.Recipient.InAppUser == nil {
-		return fmt.Errorf("in_app user missing in recipient")
+		return notification.SendResult{}, fmt.Errorf("in_app user missing in recipient")
 	}
 
-	_, err := s.db.ExecContext(ctx, `
+	res, err := s.db.ExecContext(ctx, `
 		INSERT INTO in_app_notifications
 		(notification_id, user_id, body)
 		VALUES (?, ?, ?)
@@ -36,6 +36,12 @@ func (s *Sender) Send(
 		*n.Recipient.InAppUser,
 		content.Body,
 	)
+	if err != nil {
+		return notification.SendResult{}, err
+	}
 
-	return err
+	id, _ := res.LastInsertId()
+	return notification.SendResult{
+		ProviderResponse: fmt.Sprintf("in_app_notification_id=%d", id),
+	}, nil
 }
@@ -0,0 +1,180 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+)
+
+// permanentErrors are Web API error codes that a retry cannot fix.
+var permanentErrors = map[string]bool{
+	"channel_not_found":     true,
+	"user_not_found":        true,
+	"users_not_found":       true,
+	"not_in_channel":        true,
+	"is_archived":           true,
+	"cannot_dm_bot":         true,
+	"message_not_found":     true,
+	"cant_update_message":   true,
+	"edit_window_closed":    true,
+	"user_disabled":         true,
+	"account_inactive":      true,
+	"invalid_auth":          true,
+	"not_authed":            true,
+	"token_revoked":         true,
+	"missing_scope":         true,
+	"msg_too_long":          true,
+	"no_text":               true,
+	"restricted_action":     true,
+	"invalid_blocks":        true,
+	"invalid_blocks_format": true,
+}
+
+// apiError is an ok:false reply of the Web API.
+type apiError struct {
+	Method string
+	Code   string
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("slack %s failed: %s", e.Method, e.Code)
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type postMessageResponse struct {
+	apiResponse
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+type openResponse struct {
+	apiResponse
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+// post delivers through chat.postMessage, or chat.update when the
+// notification replaces an earlier message. Thread replies go to the channel
+// of the thread.
+func (s *Sender) post(ctx context.Context, n notification.Notification, content renderer.RenderedTemplate) (notification.SendResult, error) {
+	payload := message(content)
+	method, verb := "chat.postMessage", "posted to "
+
+	switch ref := n.Reference; {
+	case ref != nil && ref.Mode == notification.ReferenceUpdate:
+		method, verb = "chat.update", "updated in "
+		payload["channel"] = ref.Channel
+		payload["ts"] = ref.MessageID
+	case ref != nil:
+		payload["channel"] = ref.Channel
+		payload["thread_ts"] = ref.MessageID
+	default:
+		if n.Recipient.SlackUser == nil || *n.Recipient.SlackUser == "" {
+			return notification.SendResult{}, notification.Permanent(errors.New("slack recipient missing"))
+		}
+		channel, err := s.conversation(ctx, *n.Recipient.SlackUser)
+		if err != nil {
+			return notification.SendResult{}, err
+		}
+		payload["channel"] = channel
+	}
+
+	var resp postMessageResponse
+	if err := s.call(ctx, method, payload, &resp); err != nil {
+		return notification.SendResult{}, err
+	}
+
+	return notification.SendResult{
+		ProviderResponse:  verb + resp.Channel + " at " + resp.TS,
+		ProviderChannel:   resp.Channel,
+		ProviderMessageID: resp.TS,
+	}, nil
+}
+
+// conversation resolves the channel to post to. User IDs are messaged
+// directly, anything else is taken as a channel ID.
+func (s *Sender) conversation(ctx context.Context, id string) (string, error) {
+	if !isUserID(id) {
+		return id, nil
+	}
+	if channel, ok := s.dms.Load(id); ok {
+		return channel.(string), nil
+	}
+
+	var resp openResponse
+	if err := s.call(ctx, "conversations.open", map[string]any{"users": id}, &resp); err != nil {
+		return "", err
+	}
+
+	s.dms.Store(id, resp.Channel.ID)
+	return resp.Channel.ID, nil
+}
+
+func isUserID(id string) bool {
+	return strings.HasPrefix(id, "U") || strings.HasPrefix(id, "W")
+}
+
+// call invokes a Web API method with a JSON body and decodes the reply into
+// out, whose embedded apiResponse reports ok:false errors.
+func (s *Sender) call(ctx context.Context, method string, payload any, out interface{ result() apiResponse }) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return notification.Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.botToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return rateLimited(resp, apiError{Method: method, Code: "ratelimited"})
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*maxResponseBytes))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack %s failed: %s %s", method, resp.Status, truncate(raw))
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("slack %s returned invalid JSON: %w", method, err)
+	}
+
+	if r := out.result(); !r.OK {
+		err := apiError{Method: method, Code: r.Error}
+		if permanentErrors[r.Error] {
+			return notification.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r apiResponse) result() apiResponse { return r }
+
+func truncate(b []byte) []byte {
+	if len(b) > maxResponseBytes {
+		return b[:maxResponseBytes]
+	}
+	return b
+}
@@ -4,22 +4,48 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/ckshitij/notify-srv/internal/config"
 	"github.com/ckshitij/notify-srv/internal/pkg/notification"
 	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
 )
 
+const maxResponseBytes = 1024
+
+const defaultAPIURL = "https://slack.com/api"
+
+// Sender posts to Slack. With a bot token it uses the Web API and delivers to
+// Recipient.SlackUser, a user ID (sent as a direct message) or a channel ID;
+// otherwise every message goes to the one incoming webhook.
 type Sender struct {
 	webhookURL string
+	botToken   string
+	apiURL     string
 	client     *http.Client
+
+	// dms caches the direct message channel opened per user ID.
+	dms sync.Map
 }
 
-func New(webhookURL string) *Sender {
+func New(cfg *config.SlackConfig) *Sender {
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
 	return &Sender{
-		webhookURL: webhookURL,
-		client:     &http.Client{},
+		webhookURL: cfg.WebhookURL,
+		botToken:   cfg.BotToken,
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		client:     &http.Client{Timeout: cfg.Timeout},
 	}
 }
 
@@ -27,13 +53,19 @@ func (s *Sender) Send(
 	ctx context.Context,
 	n notification.Notification,
 	content renderer.RenderedTemplate,
-) error {
-
-	payload := map[string]string{
-		"text": content.Body,
+) (notification.SendResult, error) {
+	if s.botToken != "" {
+		return s.post(ctx, n, content)
+	}
+	if n.Reference != nil {
+		return notification.SendResult{}, notification.Permanent(errors.New("slack threads and updates need a bot token"))
 	}
+	return s.sendWebhook(ctx, content)
+}
 
-	body, _ := json.Marshal(payload)
+func (s *Sender) sendWebhook(ctx context.Context, content renderer.RenderedTemplate) (notification.SendResult, error) {
+
+	body, _ := json.Marshal(message(content))
 
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -42,20 +74,51 @@ func (s *Sender) Send(
 		bytes.NewBuffer(body),
 	)
 	if err != nil {
-		return err
+		return notification.SendResult{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return err
+		return notification.SendResult{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("slack webhook failed: %s %v", resp.Status, err)
+	// Keep only a short excerpt of the response for attempt history
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	result := notification.SendResult{
+		ProviderResponse: fmt.Sprintf("%s: %s", resp.Status, respBody),
 	}
 
-	return nil
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return result, nil
+	case code == http.StatusTooManyRequests:
+		return result, rateLimited(resp, fmt.Errorf("slack webhook rate limited: %s", respBody))
+	case code == http.StatusRequestTimeout || code >= 500:
+		return result, fmt.Errorf("slack webhook failed: %s %s", resp.Status, respBody)
+	default:
+		// e.g. invalid_payload or a revoked webhook, sending it again will not help
+		return result, notification.Permanent(fmt.Errorf("slack webhook rejected: %s %s", resp.Status, respBody))
+	}
+}
+
+// message is the text and, for Block Kit templates, the blocks of a post.
+// Slack shows the text in notifications and where blocks cannot be rendered.
+func message(content renderer.RenderedTemplate) map[string]any {
+	msg := map[string]any{"text": content.Body}
+	if content.Blocks != "" {
+		msg["blocks"] = json.RawMessage(content.Blocks)
+	}
+	return msg
+}
+
+// rateLimited wraps err with the wait Slack asked for in Retry-After.
+func rateLimited(resp *http.Response, err error) error {
+	seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if convErr != nil || seconds < 0 {
+		return err
+	}
+	return notification.RetryAfterError{Err: err, After: time.Duration(seconds) * time.Second}
 }
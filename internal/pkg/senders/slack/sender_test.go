@@ -0,0 +1,204 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSlack answers Web API calls with the reply registered per method and
+// records the request bodies.
+type fakeSlack struct {
+	replies map[string]func(w http.ResponseWriter)
+	calls   []map[string]any
+}
+
+func (f *fakeSlack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+		w.Write([]byte(`{"ok":false,"error":"not_authed"}`))
+		return
+	}
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	body["method"] = r.URL.Path[1:]
+	f.calls = append(f.calls, body)
+
+	reply, ok := f.replies[r.URL.Path[1:]]
+	if !ok {
+		w.Write([]byte(`{"ok":false,"error":"unknown_method"}`))
+		return
+	}
+	reply(w)
+}
+
+func newBotSender(t *testing.T, f *fakeSlack) *Sender {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return New(&config.SlackConfig{BotToken: "xoxb-test", APIURL: srv.URL, Timeout: time.Second})
+}
+
+func to(id string) notification.Notification {
+	return notification.Notification{Recipient: notification.NotificationRecipient{SlackUser: &id}}
+}
+
+func TestBotSendsDirectMessage(t *testing.T) {
+	f := &fakeSlack{replies: map[string]func(w http.ResponseWriter){
+		"conversations.open": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":true,"channel":{"id":"D024BE91L"}}`))
+		},
+		"chat.postMessage": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":true,"channel":"D024BE91L","ts":"1503435956.000247"}`))
+		},
+	}}
+	s := newBotSender(t, f)
+
+	for range 2 {
+		res, err := s.Send(context.Background(), to("U0G9QF9C6"), renderer.RenderedTemplate{Body: "Hello"})
+		require.NoError(t, err)
+		require.Equal(t, "posted to D024BE91L at 1503435956.000247", res.ProviderResponse)
+	}
+
+	// the DM channel is opened once and reused
+	require.Len(t, f.calls, 3)
+	require.Equal(t, "conversations.open", f.calls[0]["method"])
+	require.Equal(t, "U0G9QF9C6", f.calls[0]["users"])
+	require.Equal(t, "D024BE91L", f.calls[1]["channel"])
+	require.Equal(t, "Hello", f.calls[1]["text"])
+}
+
+func TestBotPostsToChannel(t *testing.T) {
+	f := &fakeSlack{replies: map[string]func(w http.ResponseWriter){
+		"chat.postMessage": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":true,"channel":"C1H9RESGL","ts":"1.2"}`))
+		},
+	}}
+	s := newBotSender(t, f)
+
+	_, err := s.Send(context.Background(), to("C1H9RESGL"), renderer.RenderedTemplate{
+		Body:   "Deploy done",
+		Blocks: `[{"type":"divider"}]`,
+	})
+	require.NoError(t, err)
+	require.Len(t, f.calls, 1)
+	require.Equal(t, "C1H9RESGL", f.calls[0]["channel"])
+	require.Equal(t, "Deploy done", f.calls[0]["text"])
+	require.Equal(t, []any{map[string]any{"type": "divider"}}, f.calls[0]["blocks"])
+}
+
+func TestBotErrors(t *testing.T) {
+	f := &fakeSlack{replies: map[string]func(w http.ResponseWriter){
+		"chat.postMessage": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+		},
+	}}
+	s := newBotSender(t, f)
+
+	_, err := s.Send(context.Background(), to("C404"), renderer.RenderedTemplate{Body: "x"})
+	var apiErr apiError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "channel_not_found", apiErr.Code)
+	require.ErrorIs(t, err, notification.Permanent(apiErr))
+
+	f.replies["chat.postMessage"] = func(w http.ResponseWriter) {
+		w.Write([]byte(`{"ok":false,"error":"internal_error"}`))
+	}
+	_, err = s.Send(context.Background(), to("C404"), renderer.RenderedTemplate{Body: "x"})
+	require.EqualError(t, err, "slack chat.postMessage failed: internal_error")
+}
+
+func TestBotRateLimited(t *testing.T) {
+	f := &fakeSlack{replies: map[string]func(w http.ResponseWriter){
+		"chat.postMessage": func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	}}
+	s := newBotSender(t, f)
+
+	_, err := s.Send(context.Background(), to("C1H9RESGL"), renderer.RenderedTemplate{Body: "x"})
+	var ra notification.RetryAfterError
+	require.True(t, errors.As(err, &ra))
+	require.Equal(t, 30*time.Second, ra.After)
+}
+
+func TestBotThreadReplyAndUpdate(t *testing.T) {
+	f := &fakeSlack{replies: map[string]func(w http.ResponseWriter){
+		"chat.postMessage": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":true,"channel":"C1H9RESGL","ts":"2.0"}`))
+		},
+		"chat.update": func(w http.ResponseWriter) {
+			w.Write([]byte(`{"ok":true,"channel":"C1H9RESGL","ts":"1.0"}`))
+		},
+	}}
+	s := newBotSender(t, f)
+
+	// the reference wins over the recipient, which may be a user ID
+	n := to("U0G9QF9C6")
+	n.Reference = &notification.MessageReference{Mode: notification.ReferenceThread, Channel: "C1H9RESGL", MessageID: "1.0"}
+	res, err := s.Send(context.Background(), n, renderer.RenderedTemplate{Body: "Mitigated"})
+	require.NoError(t, err)
+	require.Equal(t, "C1H9RESGL", res.ProviderChannel)
+	require.Equal(t, "2.0", res.ProviderMessageID)
+	require.Equal(t, "chat.postMessage", f.calls[0]["method"])
+	require.Equal(t, "C1H9RESGL", f.calls[0]["channel"])
+	require.Equal(t, "1.0", f.calls[0]["thread_ts"])
+
+	n.Reference.Mode = notification.ReferenceUpdate
+	res, err = s.Send(context.Background(), n, renderer.RenderedTemplate{Body: "Resolved"})
+	require.NoError(t, err)
+	require.Equal(t, "1.0", res.ProviderMessageID)
+	require.Equal(t, "chat.update", f.calls[1]["method"])
+	require.Equal(t, "1.0", f.calls[1]["ts"])
+	require.Equal(t, "Resolved", f.calls[1]["text"])
+}
+
+func TestWebhookRejectsReference(t *testing.T) {
+	s := New(&config.SlackConfig{WebhookURL: "http://127.0.0.1:1"})
+
+	n := to("C1H9RESGL")
+	n.Reference = &notification.MessageReference{Mode: notification.ReferenceThread, Channel: "C1H9RESGL", MessageID: "1.0"}
+	_, err := s.Send(context.Background(), n, renderer.RenderedTemplate{Body: "x"})
+	require.EqualError(t, err, "slack threads and updates need a bot token")
+}
+
+func TestWebhookErrors(t *testing.T) {
+	for status, check := range map[int]func(t *testing.T, err error){
+		http.StatusBadRequest: func(t *testing.T, err error) {
+			require.ErrorAs(t, err, new(interface{ Permanent() bool }))
+		},
+		http.StatusNotFound: func(t *testing.T, err error) {
+			require.ErrorAs(t, err, new(interface{ Permanent() bool }))
+		},
+		http.StatusTooManyRequests: func(t *testing.T, err error) {
+			var ra notification.RetryAfterError
+			require.ErrorAs(t, err, &ra)
+			require.Equal(t, 30*time.Second, ra.After)
+		},
+		http.StatusServiceUnavailable: func(t *testing.T, err error) {
+			require.Error(t, err)
+			require.False(t, errors.As(err, new(interface{ Permanent() bool })))
+		},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(status)
+			w.Write([]byte("invalid_payload"))
+		}))
+		s := New(&config.SlackConfig{WebhookURL: srv.URL, Timeout: time.Second})
+
+		result, err := s.Send(context.Background(), to("C1H9RESGL"), renderer.RenderedTemplate{Body: "x"})
+		srv.Close()
+		require.Contains(t, result.ProviderResponse, "invalid_payload", status)
+		check(t, err)
+	}
+}
@@ -0,0 +1,32 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+)
+
+// LogProvider only logs that a message was sent, for development without an
+// SMS gateway. The number and body are left out of the log.
+type LogProvider struct {
+	log logger.Logger
+}
+
+func NewLogProvider(log logger.Logger) *LogProvider {
+	return &LogProvider{log: log}
+}
+
+func (p *LogProvider) Name() string { return "log" }
+
+func (p *LogProvider) Send(ctx context.Context, to, body string) (string, error) {
+	id := "log-" + uuid.NewString()
+	p.log.Info(ctx, "sms not sent, log provider",
+		logger.String("id", id),
+		logger.Int("length", len([]rune(body))),
+		logger.Int("segments", renderer.CountSMS(body).Segments),
+	)
+	return id, nil
+}
@@ -0,0 +1,77 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/logger"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+)
+
+// Provider hands a message to an SMS gateway and returns the gateway's
+// message ID. Errors follow the notification.Sender conventions:
+// notification.Permanent for refused messages, notification.RetryAfterError
+// when the gateway asks to slow down.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, to, body string) (string, error)
+}
+
+// NewProvider returns the provider selected by cfg.Provider. The log provider
+// must be chosen explicitly, so a missing setting cannot drop messages.
+func NewProvider(cfg *config.SMSConfig, log logger.Logger) (Provider, error) {
+	switch cfg.Provider {
+	case "log":
+		return NewLogProvider(log), nil
+	case "twilio":
+		return NewTwilioProvider(cfg), nil
+	case "":
+		return nil, errors.New("sms provider not set, use twilio or log")
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", cfg.Provider)
+	}
+}
+
+type Sender struct {
+	provider    Provider
+	maxSegments int
+}
+
+// New returns a Sender delivering through provider. Bodies longer than
+// maxSegments fail without being sent; 0 means no limit.
+func New(provider Provider, maxSegments int) *Sender {
+	return &Sender{provider: provider, maxSegments: maxSegments}
+}
+
+func (s *Sender) Send(
+	ctx context.Context,
+	n notification.Notification,
+	content renderer.RenderedTemplate,
+) (notification.SendResult, error) {
+
+	if n.Recipient.Phone == nil {
+		return notification.SendResult{}, notification.Permanent(errors.New("phone number missing in recipient"))
+	}
+
+	info := renderer.CountSMS(content.Body)
+	if info.Segments == 0 {
+		return notification.SendResult{}, notification.Permanent(errors.New("sms body is empty"))
+	}
+	if s.maxSegments > 0 && info.Segments > s.maxSegments {
+		return notification.SendResult{}, notification.Permanent(
+			fmt.Errorf("sms body needs %d segments, at most %d allowed", info.Segments, s.maxSegments))
+	}
+
+	id, err := s.provider.Send(ctx, *n.Recipient.Phone, content.Body)
+	if err != nil {
+		return notification.SendResult{}, err
+	}
+
+	return notification.SendResult{
+		ProviderResponse:  fmt.Sprintf("sent via %s as %s, %d %s segment(s)", s.provider.Name(), id, info.Segments, info.Encoding),
+		ProviderMessageID: id,
+	}, nil
+}
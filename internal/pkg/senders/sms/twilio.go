@@ -0,0 +1,80 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+)
+
+const maxResponseBytes = 4096
+
+// TwilioProvider sends through the Twilio Messages API, or any gateway
+// exposing the same endpoint under BaseURL.
+type TwilioProvider struct {
+	cfg    *config.SMSConfig
+	client *http.Client
+}
+
+func NewTwilioProvider(cfg *config.SMSConfig) *TwilioProvider {
+	return &TwilioProvider{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+func (p *TwilioProvider) Name() string { return "twilio" }
+
+type twilioResponse struct {
+	SID     string `json:"sid"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (p *TwilioProvider) Send(ctx context.Context, to, body string) (string, error) {
+	tw := p.cfg.Twilio
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json",
+		strings.TrimSuffix(tw.BaseURL, "/"), url.PathEscape(tw.AccountSID))
+
+	form := url.Values{"To": {to}, "From": {p.cfg.From}, "Body": {body}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", notification.Permanent(err)
+	}
+	req.SetBasicAuth(tw.AccountSID, tw.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out twilioResponse
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	_ = json.Unmarshal(raw, &out)
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		if out.SID == "" {
+			return "", fmt.Errorf("twilio response without sid: %s", raw)
+		}
+		return out.SID, nil
+	case code == http.StatusTooManyRequests:
+		err := fmt.Errorf("twilio rate limited: %s", resp.Status)
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			return "", notification.RetryAfterError{Err: err, After: time.Duration(seconds) * time.Second}
+		}
+		return "", err
+	case code >= 500:
+		return "", fmt.Errorf("twilio failed: %s", resp.Status)
+	default:
+		// invalid number, unverified sender, bad credentials: retrying will not help
+		return "", notification.Permanent(fmt.Errorf("twilio rejected: %s: %d %s", resp.Status, out.Code, out.Message))
+	}
+}
@@ -0,0 +1,90 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/stretchr/testify/require"
+)
+
+func newTwilio(srv *httptest.Server) *TwilioProvider {
+	return NewTwilioProvider(&config.SMSConfig{
+		From:    "+15005550006",
+		Timeout: time.Second,
+		Twilio:  config.TwilioConfig{BaseURL: srv.URL, AccountSID: "AC123", AuthToken: "token"},
+	})
+}
+
+func TestTwilioSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		require.Equal(t, "AC123", user)
+		require.Equal(t, "token", pass)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "+14155552671", r.PostForm.Get("To"))
+		require.Equal(t, "+15005550006", r.PostForm.Get("From"))
+		require.Equal(t, "hello", r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM1"}`))
+	}))
+	defer srv.Close()
+
+	phone := "+14155552671"
+	n := notification.Notification{Recipient: notification.NotificationRecipient{Phone: &phone}}
+	res, err := New(newTwilio(srv), 5).Send(context.Background(), n, renderer.RenderedTemplate{Body: "hello"})
+	require.NoError(t, err)
+	require.Equal(t, "SM1", res.ProviderMessageID)
+	require.Equal(t, "sent via twilio as SM1, 1 GSM-7 segment(s)", res.ProviderResponse)
+}
+
+func TestTwilioErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number"}`))
+	}))
+	defer srv.Close()
+	p := newTwilio(srv)
+
+	_, err := p.Send(context.Background(), "+1", "hi")
+	require.ErrorContains(t, err, "21211 Invalid 'To' Phone Number")
+	require.ErrorIs(t, err, notification.Permanent(errors.Unwrap(err)))
+
+	status = http.StatusTooManyRequests
+	_, err = p.Send(context.Background(), "+1", "hi")
+	var ra notification.RetryAfterError
+	require.ErrorAs(t, err, &ra)
+	require.Equal(t, 30*time.Second, ra.After)
+}
+
+func TestSendRejectsTooManySegments(t *testing.T) {
+	phone := "+14155552671"
+	n := notification.Notification{Recipient: notification.NotificationRecipient{Phone: &phone}}
+	body := make([]byte, 161)
+	for i := range body {
+		body[i] = 'a'
+	}
+	_, err := New(nil, 1).Send(context.Background(), n, renderer.RenderedTemplate{Body: string(body)})
+	require.EqualError(t, err, "sms body needs 2 segments, at most 1 allowed")
+}
+
+func TestNewProviderRequiresChoice(t *testing.T) {
+	_, err := NewProvider(&config.SMSConfig{}, nil)
+	require.Error(t, err)
+
+	_, err = NewProvider(&config.SMSConfig{Provider: "nexmo"}, nil)
+	require.Error(t, err)
+
+	p, err := NewProvider(&config.SMSConfig{Provider: "twilio"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "twilio", p.Name())
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+const maxResponseBytes = 1024
+
+// Headers set on every request. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers
+// should also reject stale timestamps to prevent replays.
+const (
+	HeaderTimestamp      = "X-Notify-Timestamp"
+	HeaderSignature      = "X-Notify-Signature"
+	HeaderNotificationID = "X-Notify-Notification-Id"
+)
+
+// Sender posts the rendered body to the recipient's URL. JSON bodies are sent
+// as application/json, anything else as text/plain.
+type Sender struct {
+	cfg    *config.WebhookConfig
+	client *http.Client
+}
+
+func New(cfg *config.WebhookConfig) *Sender {
+	return &Sender{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// a redirect could lead anywhere, outside the allowed prefixes
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Validate rejects a URL outside the configured prefixes.
+func (s *Sender) Validate(n notification.Notification) error {
+	if n.Recipient.Webhook == nil {
+		return nil
+	}
+	if !s.cfg.AllowsURL(*n.Recipient.Webhook) {
+		return shared.ErrWebhookURLNotAllowed
+	}
+	return nil
+}
+
+func (s *Sender) Send(
+	ctx context.Context,
+	n notification.Notification,
+	content renderer.RenderedTemplate,
+) (notification.SendResult, error) {
+
+	if n.Recipient.Webhook == nil {
+		return notification.SendResult{}, notification.Permanent(errors.New("webhook url missing"))
+	}
+	// checked again as the allowed prefixes may have changed since
+	if err := s.Validate(n); err != nil {
+		return notification.SendResult{}, notification.Permanent(err)
+	}
+
+	body := []byte(content.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *n.Recipient.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return notification.SendResult{}, notification.Permanent(err)
+	}
+
+	for name, value := range n.Recipient.Headers {
+		req.Header.Set(name, value)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", contentType(body))
+	req.Header.Set("User-Agent", "notify-srv")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNotificationID, strconv.FormatInt(n.ID, 10))
+	if s.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign([]byte(s.cfg.Secret), timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return notification.SendResult{}, err
+	}
+	defer resp.Body.Close()
+
+	// Keep only a short excerpt of the response for attempt history
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	result := notification.SendResult{
+		ProviderResponse: fmt.Sprintf("%s: %s", resp.Status, respBody),
+	}
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return result, nil
+	case code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500:
+		return result, retryAfter(resp, fmt.Errorf("webhook failed: %s", resp.Status))
+	default:
+		// the endpoint refused the request, sending it again will not help
+		return result, notification.Permanent(fmt.Errorf("webhook rejected: %s", resp.Status))
+	}
+}
+
+// Sign returns the signature header value of body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func contentType(body []byte) string {
+	if json.Valid(body) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// retryAfter wraps err with the wait the endpoint asked for in Retry-After.
+func retryAfter(resp *http.Response, err error) error {
+	seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if convErr != nil || seconds < 0 {
+		return err
+	}
+	return notification.RetryAfterError{Err: err, After: time.Duration(seconds) * time.Second}
+}
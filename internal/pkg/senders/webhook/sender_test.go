@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/config"
+	"github.com/ckshitij/notify-srv/internal/pkg/notification"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func newSender(srv *httptest.Server) *Sender {
+	return New(&config.WebhookConfig{
+		Secret:             "s3cret",
+		Timeout:            time.Second,
+		AllowedURLPrefixes: []string{srv.URL + "/hooks/"},
+	})
+}
+
+func to(url string) notification.Notification {
+	return notification.Notification{ID: 7, Recipient: notification.NotificationRecipient{
+		Webhook: &url,
+		Headers: map[string]string{"Authorization": "Bearer abc"},
+	}}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer srv.Close()
+
+	res, err := newSender(srv).Send(context.Background(), to(srv.URL+"/hooks/a"), renderer.RenderedTemplate{Body: `{"event":"deploy"}`})
+	require.NoError(t, err)
+	require.Equal(t, "202 Accepted: queued", res.ProviderResponse)
+
+	require.Equal(t, `{"event":"deploy"}`, string(body))
+	require.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	require.Equal(t, "Bearer abc", got.Header.Get("Authorization"))
+	require.Equal(t, "7", got.Header.Get(HeaderNotificationID))
+
+	timestamp := got.Header.Get(HeaderTimestamp)
+	require.Equal(t, Sign([]byte("s3cret"), timestamp, body), got.Header.Get(HeaderSignature))
+	require.True(t, strings.HasPrefix(got.Header.Get(HeaderSignature), "sha256="))
+}
+
+func TestSendFailures(t *testing.T) {
+	status, retryAfter := http.StatusServiceUnavailable, "20"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAfter)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	s := newSender(srv)
+	content := renderer.RenderedTemplate{Body: "deploy finished"}
+
+	_, err := s.Send(context.Background(), to(srv.URL+"/hooks/a"), content)
+	var ra notification.RetryAfterError
+	require.True(t, errors.As(err, &ra))
+	require.Equal(t, 20*time.Second, ra.After)
+
+	status = http.StatusNotFound
+	_, err = s.Send(context.Background(), to(srv.URL+"/hooks/a"), content)
+	require.EqualError(t, err, "webhook rejected: 404 Not Found")
+	require.False(t, errors.As(err, &ra))
+
+	_, err = s.Send(context.Background(), to(srv.URL+"/admin"), content)
+	require.ErrorIs(t, err, shared.ErrWebhookURLNotAllowed)
+}
+
+func TestValidate(t *testing.T) {
+	s := New(&config.WebhookConfig{AllowedURLPrefixes: []string{"https://hooks.example.com/"}})
+
+	require.NoError(t, s.Validate(to("https://hooks.example.com/ci")))
+	require.ErrorIs(t, s.Validate(to("https://hooks.example.com.evil.io/ci")), shared.ErrWebhookURLNotAllowed)
+	require.ErrorIs(t, s.Validate(to("file:///etc/passwd")), shared.ErrWebhookURLNotAllowed)
+}
@@ -0,0 +1,23 @@
+package suppression
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Post("/import", h.Import)
+	r.Get("/{id}", h.GetByID)
+	r.Delete("/{id}", h.Delete)
+
+	return r
+}
+
+func NewAdminSuppressionRoutes(service SuppressionService) http.Handler {
+	return NewHandler(service).AdminRoutes()
+}
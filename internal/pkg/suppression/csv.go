@@ -0,0 +1,91 @@
+package suppression
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// parseCSV reads suppressions from a CSV with a header row. Columns are
+// matched by name in any order: address is required; channel defaults to
+// email, reason to manual, and expires_at (RFC 3339) to never. Invalid rows
+// are reported and skipped.
+func parseCSV(r io.Reader) ([]SuppressionRequest, []ImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, shared.ErrInvalidCSV
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, nil, shared.ErrInvalidCSV
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var (
+		reqs     []SuppressionRequest
+		rejected []ImportError
+	)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rejected = append(rejected, ImportError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		req := SuppressionRequest{
+			Channel: shared.Channel(field(record, "channel")),
+			Address: field(record, "address"),
+			Reason:  Reason(field(record, "reason")),
+			Source:  field(record, "source"),
+		}
+		if req.Channel == "" {
+			req.Channel = shared.ChannelEmail
+		}
+		if req.Reason == "" {
+			req.Reason = ReasonManual
+		}
+		if v := field(record, "expires_at"); v != "" {
+			expiresAt, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				rejected = append(rejected, ImportError{Line: line, Error: "invalid expires_at, expected RFC 3339"})
+				continue
+			}
+			req.ExpiresAt = &expiresAt
+		}
+		if err := req.Validate(); err != nil {
+			rejected = append(rejected, ImportError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, rejected, nil
+}
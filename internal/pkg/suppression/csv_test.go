@@ -0,0 +1,39 @@
+package suppression
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "reason,Address,channel,expires_at\n" +
+		"hard_bounce, Bob@Example.com ,,\n" +
+		",U123,slack,2026-12-31T00:00:00Z\n" +
+		"bounced,carol@example.com,email,\n" +
+		"manual,dave@example.com,email,tomorrow\n"
+
+	reqs, rejected, err := parseCSV(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+
+	require.Equal(t, shared.ChannelEmail, reqs[0].Channel)
+	require.Equal(t, ReasonHardBounce, reqs[0].Reason)
+	require.Equal(t, "bob@example.com", reqs[0].toSuppression(SourceCSVImport).Address)
+
+	require.Equal(t, shared.ChannelSlack, reqs[1].Channel)
+	require.Equal(t, ReasonManual, reqs[1].Reason)
+	require.NotNil(t, reqs[1].ExpiresAt)
+
+	require.Equal(t, []int{4, 5}, []int{rejected[0].Line, rejected[1].Line})
+}
+
+func TestParseCSVRequiresAddressColumn(t *testing.T) {
+	_, _, err := parseCSV(strings.NewReader("email,reason\nbob@example.com,manual\n"))
+	require.ErrorIs(t, err, shared.ErrInvalidCSV)
+
+	_, _, err = parseCSV(strings.NewReader(""))
+	require.ErrorIs(t, err, shared.ErrInvalidCSV)
+}
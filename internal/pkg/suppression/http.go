@@ -0,0 +1,139 @@
+package suppression
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportSize caps the CSV body accepted by Import.
+const maxImportSize = 10 << 20
+
+type Handler struct {
+	service SuppressionService
+}
+
+func NewHandler(s SuppressionService) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req SuppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	out, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusCreated, out)
+}
+
+// Import reads a CSV request body, see parseCSV for the expected columns.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	out, err := h.service.Import(r.Context(), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "CSV exceeds the maximum import size", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid suppression ID ", http.StatusBadRequest)
+		return
+	}
+
+	out, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.List(r.Context(), parseSuppressionFilters(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid suppression ID ", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func parseSuppressionFilters(q url.Values) SuppressionFilter {
+	var filter = SuppressionFilter{}
+
+	if c := q.Get("channel"); c != "" {
+		ch := shared.Channel(c)
+		filter.Channel = &ch
+	}
+
+	if v := q.Get("reason"); v != "" {
+		reason := Reason(v)
+		filter.Reason = &reason
+	}
+
+	if a := q.Get("address"); a != "" {
+		filter.Address = &a
+	}
+
+	var err error
+	// pagination
+	if l := q.Get("limit"); l != "" {
+		filter.Limit, err = strconv.Atoi(l)
+		if err != nil || filter.Limit < 1 {
+			filter.Limit = 0
+		}
+	}
+
+	if o := q.Get("offset"); o != "" {
+		filter.Offset, err = strconv.Atoi(o)
+		if err != nil || filter.Offset < 0 {
+			filter.Offset = 0
+		}
+	}
+
+	return filter
+}
@@ -0,0 +1,111 @@
+package suppression
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type Reason string
+
+const (
+	ReasonHardBounce   Reason = "hard_bounce"
+	ReasonComplaint    Reason = "complaint"
+	ReasonUnsubscribed Reason = "unsubscribed"
+	ReasonManual       Reason = "manual"
+)
+
+// Default sources recorded when the caller does not name one.
+const (
+	SourceAdmin     = "admin"
+	SourceCSVImport = "csv_import"
+)
+
+// Suppression blocks every notification to an address on a channel until it
+// expires; entries without ExpiresAt never expire.
+type Suppression struct {
+	ID        int64          `json:"id"`
+	Channel   shared.Channel `json:"channel"`
+	Address   string         `json:"address"`
+	Reason    Reason         `json:"reason"`
+	Source    string         `json:"source"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type SuppressionRequest struct {
+	Channel   shared.Channel `json:"channel"`
+	Address   string         `json:"address"`
+	Reason    Reason         `json:"reason"`
+	Source    string         `json:"source"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+}
+
+func (r SuppressionRequest) Validate() error {
+	switch r.Channel {
+	case shared.ChannelEmail, shared.ChannelSlack, shared.ChannelInApp, shared.ChannelSMS:
+	default:
+		return shared.ErrInvalidSuppression
+	}
+	switch r.Reason {
+	case ReasonHardBounce, ReasonComplaint, ReasonUnsubscribed, ReasonManual:
+	default:
+		return shared.ErrInvalidSuppression
+	}
+	if NormalizeAddress(r.Channel, r.Address) == "" {
+		return shared.ErrInvalidSuppression
+	}
+	return nil
+}
+
+func (r SuppressionRequest) toSuppression(defaultSource string) Suppression {
+	source := r.Source
+	if source == "" {
+		source = defaultSource
+	}
+	var expiresAt *time.Time
+	if r.ExpiresAt != nil {
+		t := r.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+	return Suppression{
+		Channel:   r.Channel,
+		Address:   NormalizeAddress(r.Channel, r.Address),
+		Reason:    r.Reason,
+		Source:    source,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// NormalizeAddress returns the form addresses are stored and looked up in:
+// trimmed, and lower-cased for email, whose mailboxes are matched case
+// insensitively in practice.
+func NormalizeAddress(channel shared.Channel, address string) string {
+	address = strings.TrimSpace(address)
+	if channel == shared.ChannelEmail {
+		address = strings.ToLower(address)
+	}
+	return address
+}
+
+type SuppressionFilter struct {
+	Channel *shared.Channel
+	Reason  *Reason
+	Address *string
+	Limit   int
+	Offset  int
+}
+
+// ImportResult reports a CSV import; rows that failed validation are listed
+// by their line number and skipped, the rest are stored.
+type ImportResult struct {
+	Imported int           `json:"imported"`
+	Rejected []ImportError `json:"rejected,omitempty"`
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
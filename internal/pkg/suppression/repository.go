@@ -0,0 +1,16 @@
+package suppression
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type SuppressionRepository interface {
+	Upsert(ctx context.Context, s Suppression) (int64, error)
+	UpsertMany(ctx context.Context, ss []Suppression, chunkSize int) error
+	GetByID(ctx context.Context, id int64) (*Suppression, error)
+	GetActive(ctx context.Context, channel shared.Channel, address string) (*Suppression, error)
+	List(ctx context.Context, filter SuppressionFilter) ([]*Suppression, error)
+	Delete(ctx context.Context, id int64) (bool, error)
+}
@@ -0,0 +1,17 @@
+package suppression
+
+import (
+	"context"
+	"io"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type SuppressionService interface {
+	Create(ctx context.Context, req SuppressionRequest) (*Suppression, error)
+	Import(ctx context.Context, r io.Reader) (*ImportResult, error)
+	GetByID(ctx context.Context, id int64) (*Suppression, error)
+	List(ctx context.Context, filter SuppressionFilter) ([]*Suppression, error)
+	Delete(ctx context.Context, id int64) error
+	Lookup(ctx context.Context, channel shared.Channel, address string) (*Suppression, error)
+}
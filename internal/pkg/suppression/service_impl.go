@@ -0,0 +1,84 @@
+package suppression
+
+import (
+	"context"
+	"io"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// importChunkSize bounds the rows written by a single INSERT during a CSV import.
+const importChunkSize = 500
+
+type ServiceImpl struct {
+	repo SuppressionRepository
+}
+
+func NewSuppressionService(repo SuppressionRepository) SuppressionService {
+	return &ServiceImpl{
+		repo: repo,
+	}
+}
+
+// Create adds an address to the list, or replaces the reason, source and
+// expiry of an existing entry for the same channel and address.
+func (s *ServiceImpl) Create(ctx context.Context, req SuppressionRequest) (*Suppression, error) {
+	id, err := s.repo.Upsert(ctx, req.toSuppression(SourceAdmin))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *ServiceImpl) Import(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	reqs, rejected, err := parseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]Suppression, 0, len(reqs))
+	for _, req := range reqs {
+		ss = append(ss, req.toSuppression(SourceCSVImport))
+	}
+
+	if len(ss) > 0 {
+		if err := s.repo.UpsertMany(ctx, ss, importChunkSize); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ImportResult{Imported: len(ss), Rejected: rejected}, nil
+}
+
+func (s *ServiceImpl) GetByID(ctx context.Context, id int64) (*Suppression, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *ServiceImpl) List(ctx context.Context, filter SuppressionFilter) ([]*Suppression, error) {
+	if filter.Address != nil && filter.Channel != nil {
+		address := NormalizeAddress(*filter.Channel, *filter.Address)
+		filter.Address = &address
+	}
+	return s.repo.List(ctx, filter)
+}
+
+func (s *ServiceImpl) Delete(ctx context.Context, id int64) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return shared.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Lookup returns the unexpired suppression of address on channel, or
+// shared.ErrRecordNotFound when it may be sent to.
+func (s *ServiceImpl) Lookup(ctx context.Context, channel shared.Channel, address string) (*Suppression, error) {
+	address = NormalizeAddress(channel, address)
+	if address == "" {
+		return nil, shared.ErrRecordNotFound
+	}
+	return s.repo.GetActive(ctx, channel, address)
+}
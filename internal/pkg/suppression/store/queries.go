@@ -0,0 +1,101 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+)
+
+const (
+	// LAST_INSERT_ID(id) makes the existing row's id the insert id when the
+	// address was already suppressed.
+	UpsertSuppressionQuery = `
+		INSERT INTO suppressions
+			(channel, address, reason, source, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			reason = VALUES(reason),
+			source = VALUES(source),
+			expires_at = VALUES(expires_at)
+	`
+
+	suppressionColumns = `
+		id, channel, address, reason, source,
+		expires_at, created_at, updated_at
+	`
+
+	GetSuppressionByIDQuery = `SELECT ` + suppressionColumns + `
+		FROM suppressions
+		WHERE id = ?
+	`
+
+	GetActiveSuppressionQuery = `SELECT ` + suppressionColumns + `
+		FROM suppressions
+		WHERE channel = ? AND address = ?
+		  AND (expires_at IS NULL OR expires_at > UTC_TIMESTAMP())
+		LIMIT 1
+	`
+
+	DeleteSuppressionQuery = `
+		DELETE FROM suppressions
+		WHERE id = ?
+	`
+)
+
+func buildUpsertSuppressionsQuery(ss []suppression.Suppression) (string, []any) {
+	query := `INSERT INTO suppressions (channel, address, reason, source, expires_at) VALUES `
+	args := make([]any, 0, len(ss)*5)
+
+	for i, s := range ss {
+		if i > 0 {
+			query += ", "
+		}
+		query += "(?, ?, ?, ?, ?)"
+		args = append(args, s.Channel, s.Address, s.Reason, s.Source, s.ExpiresAt)
+	}
+
+	query += ` ON DUPLICATE KEY UPDATE reason = VALUES(reason), source = VALUES(source), expires_at = VALUES(expires_at)`
+	return query, args
+}
+
+func buildListSuppressionsQuery(filter suppression.SuppressionFilter) (string, []any) {
+	query := `SELECT ` + suppressionColumns + ` FROM suppressions`
+	args := []any{}
+	conditions := []string{}
+
+	if filter.Channel != nil {
+		conditions = append(conditions, "channel = ?")
+		args = append(args, *filter.Channel)
+	}
+	if filter.Reason != nil {
+		conditions = append(conditions, "reason = ?")
+		args = append(args, *filter.Reason)
+	}
+	if filter.Address != nil {
+		conditions = append(conditions, "address = ?")
+		args = append(args, *filter.Address)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	// pagination
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		if filter.Limit <= 0 {
+			// MySQL only accepts OFFSET together with LIMIT
+			query += " LIMIT 18446744073709551615"
+		}
+		query += " OFFSET ?"
+		args = append(args, filter.Offset)
+	}
+
+	return query, args
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type suppressionStore struct {
+	db  *mysqlwrapper.DB
+	log logger.Logger
+}
+
+func NewSuppressionRepository(db *mysqlwrapper.DB, log logger.Logger) suppression.SuppressionRepository {
+	return &suppressionStore{db, log}
+}
+
+func (r *suppressionStore) Upsert(ctx context.Context, s suppression.Suppression) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "UpsertSuppression", UpsertSuppressionQuery,
+		s.Channel,
+		s.Address,
+		s.Reason,
+		s.Source,
+		s.ExpiresAt,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to upsert suppression", logger.String("channel", string(s.Channel)), logger.Error(err))
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpsertMany writes the suppressions in chunks within a single transaction,
+// so an import is stored completely or not at all.
+func (r *suppressionStore) UpsertMany(ctx context.Context, ss []suppression.Suppression, chunkSize int) error {
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		for start := 0; start < len(ss); start += chunkSize {
+			end := min(start+chunkSize, len(ss))
+			query, args := buildUpsertSuppressionsQuery(ss[start:end])
+			if _, err := tx.ExecContext(ctx, "UpsertSuppressions", query, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to import suppressions", logger.Int("count", len(ss)), logger.Error(err))
+	}
+	return err
+}
+
+func (r *suppressionStore) GetByID(ctx context.Context, id int64) (*suppression.Suppression, error) {
+	s, err := scanSuppression(r.db.QueryRowContext(ctx, "GetSuppressionByID", GetSuppressionByIDQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrRecordNotFound
+	}
+	if err != nil {
+		r.log.Error(ctx, "failed to get suppression", logger.Int64("suppressionID", id), logger.Error(err))
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *suppressionStore) GetActive(ctx context.Context, channel shared.Channel, address string) (*suppression.Suppression, error) {
+	s, err := scanSuppression(r.db.QueryRowContext(ctx, "GetActiveSuppression", GetActiveSuppressionQuery, channel, address))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrRecordNotFound
+	}
+	if err != nil {
+		r.log.Error(ctx, "failed to look up suppression", logger.String("channel", string(channel)), logger.Error(err))
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *suppressionStore) List(ctx context.Context, filter suppression.SuppressionFilter) ([]*suppression.Suppression, error) {
+	query, args := buildListSuppressionsQuery(filter)
+	rows, err := r.db.QueryContext(ctx, "ListSuppressions", query, args...)
+	if err != nil {
+		r.log.Error(ctx, "failed to list suppressions", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out = []*suppression.Suppression{}
+	for rows.Next() {
+		s, err := scanSuppression(rows)
+		if err != nil {
+			r.log.Error(ctx, "failed to scan list suppressions", logger.Error(err))
+			return nil, err
+		}
+		out = append(out, s)
+	}
+
+	return out, rows.Err()
+}
+
+func (r *suppressionStore) Delete(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteSuppression", DeleteSuppressionQuery, id)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete suppression", logger.Int64("suppressionID", id), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func scanSuppression(row scanner) (*suppression.Suppression, error) {
+	var s suppression.Suppression
+	if err := row.Scan(
+		&s.ID,
+		&s.Channel,
+		&s.Address,
+		&s.Reason,
+		&s.Source,
+		&s.ExpiresAt,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,90 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	texttemplate "text/template"
+	"text/template/parse"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/blockkit"
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+)
+
+// validateBlocks checks a blocks template at creation without depending on
+// its data: the layout skeleton, with every action printing a placeholder,
+// must be a valid Block Kit layout. The rendered layout is checked again on
+// every render.
+func validateBlocks(blocks string) error {
+	if blocks == "" {
+		return nil
+	}
+
+	t, err := texttemplate.New("blocks").Parse(blocks)
+	if err != nil {
+		return fmt.Errorf("invalid blocks template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writeSkeleton(&buf, t, t.Tree.Root, 0)
+	if _, _, err := blockkit.Parse(buf.Bytes()); err != nil {
+		return fmt.Errorf("invalid blocks: %w", err)
+	}
+	return nil
+}
+
+// maxSkeletonDepth bounds nested {{template}} calls, which may recurse.
+const maxSkeletonDepth = 8
+
+// writeSkeleton writes the text of a template with every action printing 0,
+// which is valid both inside a JSON string and as a JSON value. Data cannot
+// add structure, since RenderJSON escapes it. A range body is written once
+// and an if takes its else branch, so a separator such as {{if $i}},{{end}}
+// is left out.
+func writeSkeleton(buf *bytes.Buffer, t *texttemplate.Template, node parse.Node, depth int) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			writeSkeleton(buf, t, child, depth)
+		}
+	case *parse.TextNode:
+		buf.Write(n.Text)
+	case *parse.ActionNode:
+		// a variable declaration prints nothing
+		if len(n.Pipe.Decl) == 0 {
+			buf.WriteString("0")
+		}
+	case *parse.IfNode:
+		writeSkeleton(buf, t, n.ElseList, depth)
+	case *parse.RangeNode:
+		writeSkeleton(buf, t, n.List, depth)
+	case *parse.WithNode:
+		writeSkeleton(buf, t, n.List, depth)
+	case *parse.TemplateNode:
+		if called := t.Lookup(n.Name); called != nil && called.Tree != nil && depth < maxSkeletonDepth {
+			writeSkeleton(buf, t, called.Tree.Root, depth+1)
+		}
+	}
+}
+
+// renderBlocks adds the rendered layout to rendered, rejecting output that is
+// not a valid Block Kit layout.
+func renderBlocks(r renderer.Renderer, tpl *Template, data map[string]any, rendered renderer.RenderedTemplate) (renderer.RenderedTemplate, error) {
+	out, err := r.RenderJSON(tpl.Blocks, data)
+	if err != nil {
+		return rendered, err
+	}
+
+	raw, blocks, err := blockkit.Parse([]byte(out))
+	if err != nil {
+		return rendered, fmt.Errorf("render blocks: %w", err)
+	}
+
+	rendered.Blocks = string(raw)
+	if tpl.Body == "" {
+		rendered.Body = blockkit.FallbackText(blocks)
+	}
+	return rendered, nil
+}
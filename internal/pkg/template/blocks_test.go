@@ -0,0 +1,38 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderContentBlocks(t *testing.T) {
+	tpl := &Template{
+		Blocks: `[{"type": "section", "text": {"type": "mrkdwn", "text": "Hi {{.Name}}"}}]`,
+	}
+
+	out, err := RenderContent(renderer.NewGoTemplateRenderer(), tpl, map[string]any{"Name": `"Bob"`})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"type":"section","text":{"type":"mrkdwn","text":"Hi \"Bob\""}}]`, out.Blocks)
+	require.Equal(t, `Hi "Bob"`, out.Body)
+
+	tpl.Blocks = `[{"type": "section"}]`
+	_, err = RenderContent(renderer.NewGoTemplateRenderer(), tpl, nil)
+	require.Error(t, err)
+}
+
+func TestValidateBlocks(t *testing.T) {
+	require.NoError(t, validateBlocks(""))
+	require.NoError(t, validateBlocks(`[{"type": "header", "text": {"type": "plain_text", "text": "{{.Title}}"}}]`))
+	// needs data to render
+	require.NoError(t, validateBlocks(`[{"type": "section", "text": {"type": "mrkdwn", "text": "{{.User.Name}}"}}]`))
+	require.NoError(t, validateBlocks(`[{{range $i, $item := .Items}}{{if $i}},{{end}}{"type": "section", "text": {"type": "mrkdwn", "text": "{{index $item 0}}"}}{{end}}]`))
+	require.NoError(t, validateBlocks(`[{"type": "header", "text": {"type": "plain_text", "text": "{{.Title}}"}}{{if .User.Admin}},{"type": "divider"}{{end}}]`))
+	require.Error(t, validateBlocks(`[{"type": "section", "text": {"type": "mrkdwn", "text": "{{.User.Name}}"}}, {"type": "header"}]`))
+	require.Error(t, validateBlocks(`[{{range .Items}}{"type": "section"}{{end}}]`))
+
+	require.Error(t, validateBlocks(`[{"type": "header", "text": {{.Title}`))
+	require.Error(t, validateBlocks(`[{"type": "header"}]`))
+	require.Error(t, validateBlocks(`{{.Title}}`))
+}
@@ -31,13 +31,21 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateBlocks(req.Blocks); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	tpl := Template{
 		Name:        req.Name,
 		Description: req.Description,
 		Channel:     req.Channel,
 		Type:        shared.UserTemplate,
+		Category:    req.Category,
 		Subject:     req.Subject,
 		Body:        req.Body,
+		HTMLBody:    req.HTMLBody,
+		Blocks:      req.Blocks,
 	}
 
 	id, err := h.service.Create(r.Context(), tpl)
@@ -147,6 +155,10 @@ func parseTemplateFilters(q url.Values) TemplateFilter {
 		filter.Name = &t
 	}
 
+	if c := q.Get("category"); c != "" {
+		filter.Category = &c
+	}
+
 	var err error
 	// pagination
 	if l := q.Get("limit"); l != "" {
@@ -3,6 +3,7 @@ package template
 import (
 	"time"
 
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
 	"github.com/ckshitij/notify-srv/internal/shared"
 )
 
@@ -12,9 +13,12 @@ type Template struct {
 	Description string              `json:"description"`
 	Channel     shared.Channel      `json:"channel"`
 	Type        shared.TemplateType `json:"type"`
+	Category    string              `json:"category"`
 	IsActive    bool                `json:"is_active"`
 	Subject     string              `json:"subject"`
 	Body        string              `json:"body"`
+	HTMLBody    string              `json:"html_body,omitempty"`
+	Blocks      string              `json:"blocks,omitempty"`
 	CreatedBy   int64               `json:"created_by,omitempty"`
 	UpdatedBy   int64               `json:"updated_by,omitempty"`
 	CreatedAt   time.Time           `json:"created_at"`
@@ -25,8 +29,15 @@ type CreateTemplateRequest struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	Channel     shared.Channel `json:"channel"`
+	Category    string         `json:"category"`
 	Subject     string         `json:"subject"`
 	Body        string         `json:"body"`
+	// HTMLBody is only supported for email; without Body the plain-text
+	// alternative is derived from it.
+	HTMLBody string `json:"html_body"`
+	// Blocks is a Slack Block Kit layout template rendering to a JSON array
+	// of blocks. Without Body the notification text is derived from it.
+	Blocks string `json:"blocks"`
 }
 
 func (r CreateTemplateRequest) Validate() error {
@@ -36,23 +47,43 @@ func (r CreateTemplateRequest) Validate() error {
 	if r.Channel == "" {
 		return shared.ErrRequiredFieldChannel
 	}
-	if r.Body == "" {
+	if r.Body == "" && r.HTMLBody == "" && r.Blocks == "" {
 		return shared.ErrRequiredFieldBody
 	}
+	if r.HTMLBody != "" && r.Channel != shared.ChannelEmail {
+		return shared.ErrHTMLBodyEmailOnly
+	}
+	if r.Blocks != "" && r.Channel != shared.ChannelSlack {
+		return shared.ErrBlocksSlackOnly
+	}
+	if r.Channel == shared.ChannelSMS && r.Subject != "" {
+		return shared.ErrSubjectNotAllowedForSMS
+	}
 	if r.Channel == shared.ChannelEmail && r.Subject == "" {
 		return shared.ErrRequiredFieldSubject
 	}
 	return nil
 }
 
+// DefaultCategory is used for templates created without a category. Users set
+// notification preferences per template category.
+const DefaultCategory = "general"
+
 type RenderRequest struct {
 	TemplateKeyValue map[string]any `json:"template_key_value"`
 }
 
+// RenderedTemplate is a template with its content rendered.
+type RenderedTemplate struct {
+	*Template
+	SMS *renderer.SMSInfo `json:"sms,omitempty"` // sms templates only
+}
+
 type TemplateFilter struct {
 	Name     *string
 	Channel  *shared.Channel
 	Type     *shared.TemplateType
+	Category *string
 	IsActive *bool
 	Limit    int
 	Offset   int
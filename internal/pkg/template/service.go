@@ -10,5 +10,5 @@ type TemplateService interface {
 	List(ctx context.Context, filter TemplateFilter) ([]*Template, error)
 	CacheReloadSystemTemplates(ctx context.Context) error
 	InvalidateTemplateCache(ctx context.Context, templateID int64) error
-	Render(ctx context.Context, templateID int64, data map[string]any) (*Template, error)
+	Render(ctx context.Context, templateID int64, data map[string]any) (*RenderedTemplate, error)
 }
@@ -23,6 +23,9 @@ func (s *ServiceImpl) Create(ctx context.Context, tpl Template) (int64, error) {
 	if tpl.Type == shared.SystemTemplate {
 		return -1, shared.ErrSystemTemplateNotPermitted
 	}
+	if tpl.Category == "" {
+		tpl.Category = DefaultCategory
+	}
 	return s.repo.Create(ctx, tpl)
 }
 
@@ -38,23 +41,58 @@ func (s *ServiceImpl) InvalidateTemplateCache(ctx context.Context, templateID in
 	return s.repo.InvalidateTemplateCache(ctx, templateID)
 }
 
-func (s *ServiceImpl) Render(ctx context.Context, templateID int64, data map[string]any) (*Template, error) {
+func (s *ServiceImpl) Render(ctx context.Context, templateID int64, data map[string]any) (*RenderedTemplate, error) {
 
 	tpl, err := s.repo.GetByID(ctx, templateID)
 	if err != nil {
 		return nil, err
 	}
 
-	rendered, err := s.renderer.Render(tpl.Subject, tpl.Body, data)
+	rendered, err := RenderContent(s.renderer, tpl, data)
 	if err != nil {
 		return nil, err
 	}
 
 	tpl.Subject = rendered.Subject
 	tpl.Body = rendered.Body
-	return tpl, nil
+	tpl.HTMLBody = rendered.HTMLBody
+	tpl.Blocks = rendered.Blocks
+	return &RenderedTemplate{Template: tpl, SMS: rendered.SMS}, nil
 }
 
 func (s *ServiceImpl) List(ctx context.Context, filter TemplateFilter) ([]*Template, error) {
 	return s.repo.List(ctx, filter)
 }
+
+// RenderContent renders every part of tpl. Templates with only an HTML body
+// or only blocks get their plain-text body derived from the rendered output.
+func RenderContent(r renderer.Renderer, tpl *Template, data map[string]any) (renderer.RenderedTemplate, error) {
+	if tpl.Channel == shared.ChannelWebhook && isJSONTemplate(tpl.Body) {
+		return renderJSONBody(r, tpl, data)
+	}
+
+	rendered, err := r.Render(tpl.Subject, tpl.Body, data)
+	if err != nil {
+		return rendered, err
+	}
+	if tpl.Blocks != "" {
+		return renderBlocks(r, tpl, data, rendered)
+	}
+	if tpl.Channel == shared.ChannelSMS {
+		info := renderer.CountSMS(rendered.Body)
+		rendered.SMS = &info
+		return rendered, nil
+	}
+	if tpl.HTMLBody == "" {
+		return rendered, nil
+	}
+
+	rendered.HTMLBody, err = r.RenderHTML(tpl.HTMLBody, data)
+	if err != nil {
+		return rendered, err
+	}
+	if tpl.Body == "" {
+		rendered.Body = renderer.HTMLToText(rendered.HTMLBody)
+	}
+	return rendered, nil
+}
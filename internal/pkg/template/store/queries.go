@@ -5,8 +5,8 @@ import "github.com/ckshitij/notify-srv/internal/pkg/template"
 const (
 	CreateTemplateQuery = `
 		INSERT INTO templates
-			(name, description, channel, type, subject, body, created_by, updated_by)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			(name, description, channel, type, category, subject, body, html_body, blocks, created_by, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)
 	`
 
 	GetTemplateByIDQuery = `
@@ -16,9 +16,12 @@ const (
 			description,
 			channel,
 			type,
+			category,
 			is_active,
 			IFNULL(subject, ''), 
 			body,
+			IFNULL(html_body, ''),
+			IFNULL(blocks, ''),
 			created_by,
 			updated_by,
 			created_at,
@@ -34,9 +37,12 @@ const (
 			description,
 			channel,
 			type,
+			category,
 			is_active,
 			IFNULL(subject, ''),
 			body,
+			IFNULL(html_body, ''),
+			IFNULL(blocks, ''),
 			created_by,
 			updated_by,
 			created_at,
@@ -48,8 +54,8 @@ const (
 
 func buildGetAllTemplatesQuery(filter template.TemplateFilter) (string, []any) {
 	query := `
-		SELECT id, name, description, channel, type, is_active, IFNULL(subject, ''), 
-			body, created_by, updated_by, created_at, updated_at 
+		SELECT id, name, description, channel, type, category, is_active, IFNULL(subject, ''), 
+			body, IFNULL(html_body, ''), IFNULL(blocks, ''), created_by, updated_by, created_at, updated_at 
 		FROM templates
 		WHERE 1=1
 	`
@@ -71,6 +77,11 @@ func buildGetAllTemplatesQuery(filter template.TemplateFilter) (string, []any) {
 		args = append(args, *filter.IsActive)
 	}
 
+	if filter.Category != nil {
+		query += " AND category = ?"
+		args = append(args, *filter.Category)
+	}
+
 	if filter.Name != nil {
 		query += " AND name = ?"
 		args = append(args, *filter.Name)
@@ -40,7 +40,7 @@ func NewTemplateRepository(db *mysqlwrapper.DB, rdb *redis.Client, log logger.Lo
 }
 
 func (r *templateStore) Create(ctx context.Context, tpl template.Template) (int64, error) {
-	query, args := CreateTemplateQuery, []any{tpl.Name, tpl.Description, tpl.Channel, tpl.Type, tpl.Subject, tpl.Body, tpl.CreatedBy, tpl.UpdatedBy}
+	query, args := CreateTemplateQuery, []any{tpl.Name, tpl.Description, tpl.Channel, tpl.Type, tpl.Category, tpl.Subject, tpl.Body, tpl.HTMLBody, tpl.Blocks, tpl.CreatedBy, tpl.UpdatedBy}
 	result, err := r.db.ExecContext(ctx, "CreateNotification", query, args...)
 	if err != nil {
 		if isDuplicateKey(err) {
@@ -73,9 +73,12 @@ func (r *templateStore) GetByID(ctx context.Context, templateID int64) (*templat
 		&t.Description,
 		&t.Channel,
 		&t.Type,
+		&t.Category,
 		&t.IsActive,
 		&t.Subject,
 		&t.Body,
+		&t.HTMLBody,
+		&t.Blocks,
 		&t.CreatedBy,
 		&t.UpdatedBy,
 		&t.CreatedAt,
@@ -157,9 +160,12 @@ func (r *templateStore) List(ctx context.Context, filter template.TemplateFilter
 			&t.Description,
 			&t.Channel,
 			&t.Type,
+			&t.Category,
 			&t.IsActive,
 			&t.Subject,
 			&t.Body,
+			&t.HTMLBody,
+			&t.Blocks,
 			&t.CreatedBy,
 			&t.UpdatedBy,
 			&t.CreatedAt,
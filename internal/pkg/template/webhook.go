@@ -0,0 +1,29 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+)
+
+// isJSONTemplate reports whether a webhook body is a JSON document template,
+// an object or array, rather than plain text.
+func isJSONTemplate(body string) bool {
+	body = strings.TrimSpace(body)
+	return strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[")
+}
+
+// renderJSONBody renders a JSON webhook body, escaping string data for its
+// JSON context, and rejects output that is not valid JSON.
+func renderJSONBody(r renderer.Renderer, tpl *Template, data map[string]any) (renderer.RenderedTemplate, error) {
+	body, err := r.RenderJSON(tpl.Body, data)
+	if err != nil {
+		return renderer.RenderedTemplate{}, err
+	}
+	if !json.Valid([]byte(body)) {
+		return renderer.RenderedTemplate{}, errors.New("render body: webhook body is not valid JSON")
+	}
+	return renderer.RenderedTemplate{Body: body}, nil
+}
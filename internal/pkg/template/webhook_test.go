@@ -0,0 +1,24 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/renderer"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderContentWebhookJSON(t *testing.T) {
+	tpl := &Template{
+		Channel: shared.ChannelWebhook,
+		Body:    `{"event": "deploy", "service": "{{.Service}}", "replicas": {{.Replicas}}}`,
+	}
+
+	out, err := RenderContent(renderer.NewGoTemplateRenderer(), tpl, map[string]any{"Service": `api "v2"`, "Replicas": 3})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"event": "deploy", "service": "api \"v2\"", "replicas": 3}`, out.Body)
+
+	tpl.Body = `{"event": {{.Event}}}`
+	_, err = RenderContent(renderer.NewGoTemplateRenderer(), tpl, map[string]any{"Event": "deploy"})
+	require.Error(t, err)
+}
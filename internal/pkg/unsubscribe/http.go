@@ -0,0 +1,103 @@
+package unsubscribe
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+// sourceUnsubscribeLink is recorded on suppressions created by an unsubscribe link.
+const sourceUnsubscribeLink = "unsubscribe_link"
+
+// pages are shown to recipients opening an unsubscribe link in a browser.
+var pages = template.Must(template.New("").Parse(`
+{{define "confirm"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<p>Stop receiving {{.Category}} messages at {{.Address}}?</p>
+<form method="post"><input type="hidden" name="List-Unsubscribe" value="One-Click"><button type="submit">Unsubscribe</button></form>
+</body></html>
+{{end}}
+{{define "done"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body><p>You have been unsubscribed.</p></body></html>
+{{end}}`))
+
+// PreferenceWriter stores the opt-out of a known user.
+type PreferenceWriter interface {
+	SetPreferences(ctx context.Context, userID string, prefs []user.Preference) ([]*user.Preference, error)
+}
+
+// SuppressionWriter stores the opt-out of an address without a user.
+type SuppressionWriter interface {
+	Create(ctx context.Context, req suppression.SuppressionRequest) (*suppression.Suppression, error)
+}
+
+type Handler struct {
+	secret       string
+	users        PreferenceWriter
+	suppressions SuppressionWriter
+}
+
+func NewHandler(secret string, users PreferenceWriter, suppressions SuppressionWriter) *Handler {
+	return &Handler{secret, users, suppressions}
+}
+
+// Confirm shows the page an unsubscribe link opens in a browser. It changes
+// nothing, so link scanners following the URL do not unsubscribe anyone; the
+// page's form POSTs to Unsubscribe.
+func (h *Handler) Confirm(w http.ResponseWriter, r *http.Request) {
+	t, err := Verify(h.secret, chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = pages.ExecuteTemplate(w, "confirm", t)
+}
+
+// Unsubscribe is the RFC 8058 one-click target. Recipients known by user ID
+// opt out of the token's category on its channel; others have the address
+// suppressed on the channel altogether.
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	t, err := Verify(h.secret, chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	if t.UserID != "" {
+		_, err = h.users.SetPreferences(r.Context(), t.UserID, []user.Preference{{
+			UserID:   t.UserID,
+			Category: t.Category,
+			Channel:  t.Channel,
+			Mode:     user.PreferenceDisabled,
+		}})
+	} else {
+		_, err = h.suppressions.Create(r.Context(), suppression.SuppressionRequest{
+			Channel: t.Channel,
+			Address: t.Address,
+			Reason:  suppression.ReasonUnsubscribed,
+			Source:  sourceUnsubscribeLink,
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	// the confirmation form of a browser, not a one-click client
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = pages.ExecuteTemplate(w, "done", nil)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]string{"status": "unsubscribed"})
+}
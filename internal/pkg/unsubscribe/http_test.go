@@ -0,0 +1,64 @@
+package unsubscribe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/pkg/suppression"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSuppressions []suppression.SuppressionRequest
+
+func (f *fakeSuppressions) Create(_ context.Context, req suppression.SuppressionRequest) (*suppression.Suppression, error) {
+	*f = append(*f, req)
+	return &suppression.Suppression{}, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) SetPreferences(context.Context, string, []user.Preference) ([]*user.Preference, error) {
+	return nil, nil
+}
+
+func TestConfirmThenUnsubscribe(t *testing.T) {
+	suppressions := &fakeSuppressions{}
+	srv := httptest.NewServer(NewUnsubscribeRoutes("secret", fakeUsers{}, suppressions))
+	defer srv.Close()
+
+	token, err := Sign("secret", Token{Channel: shared.ChannelEmail, Address: "bob@example.com", Category: "marketing"})
+	require.NoError(t, err)
+
+	// opening the link only shows the form
+	resp, err := http.Get(srv.URL + "/" + token)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), `<form method="post">`)
+	require.Contains(t, string(body), "bob@example.com")
+	require.Empty(t, *suppressions)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/"+token, strings.NewReader("List-Unsubscribe=One-Click"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "You have been unsubscribed.")
+	require.Len(t, *suppressions, 1)
+
+	resp, err = http.Get(srv.URL + "/not-a-token")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
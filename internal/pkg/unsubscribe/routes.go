@@ -0,0 +1,20 @@
+package unsubscribe
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/{token}", h.Confirm)
+	r.Post("/{token}", h.Unsubscribe)
+
+	return r
+}
+
+func NewUnsubscribeRoutes(secret string, users PreferenceWriter, suppressions SuppressionWriter) http.Handler {
+	return NewHandler(secret, users, suppressions).Routes()
+}
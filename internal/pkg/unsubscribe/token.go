@@ -0,0 +1,65 @@
+package unsubscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// Token identifies what a recipient unsubscribes from. Tokens do not expire:
+// unsubscribe links must keep working for as long as the email is kept.
+type Token struct {
+	Channel  shared.Channel `json:"ch"`
+	Address  string         `json:"addr"`
+	UserID   string         `json:"uid,omitempty"`
+	Category string         `json:"cat"`
+}
+
+// Sign encodes the token as base64url(JSON) "." base64url(HMAC-SHA256), which
+// is safe to use as a URL path segment.
+func Sign(secret string, t Token) (string, error) {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac(secret, encoded)), nil
+}
+
+// Verify checks the signature of a token created by Sign and decodes it. With
+// an empty secret links are disabled and anyone could sign, so every token is
+// rejected.
+func Verify(secret, token string) (Token, error) {
+	var t Token
+
+	if secret == "" {
+		return t, shared.ErrInvalidUnsubscribeToken
+	}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return t, shared.ErrInvalidUnsubscribeToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, encoded)) {
+		return t, shared.ErrInvalidUnsubscribeToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return t, shared.ErrInvalidUnsubscribeToken
+	}
+	if err := json.Unmarshal(payload, &t); err != nil || t.Address == "" || t.Category == "" {
+		return t, shared.ErrInvalidUnsubscribeToken
+	}
+	return t, nil
+}
+
+func mac(secret, encoded string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
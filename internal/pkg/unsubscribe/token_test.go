@@ -0,0 +1,47 @@
+package unsubscribe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	in := Token{Channel: shared.ChannelEmail, Address: "bob@example.com", UserID: "u123", Category: "marketing"}
+
+	token, err := Sign("secret", in)
+	require.NoError(t, err)
+	require.NotContains(t, token, "/")
+
+	out, err := Verify("secret", token)
+	require.NoError(t, err)
+	require.Equal(t, in, out)
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	token, err := Sign("secret", Token{Channel: shared.ChannelEmail, Address: "bob@example.com", Category: "marketing"})
+	require.NoError(t, err)
+
+	_, err = Verify("other-secret", token)
+	require.ErrorIs(t, err, shared.ErrInvalidUnsubscribeToken)
+
+	forged, err := Sign("other-secret", Token{Channel: shared.ChannelEmail, Address: "alice@example.com", Category: "marketing"})
+	require.NoError(t, err)
+	payload, _, _ := strings.Cut(forged, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	_, err = Verify("secret", payload+"."+sig)
+	require.ErrorIs(t, err, shared.ErrInvalidUnsubscribeToken)
+
+	_, err = Verify("secret", "not-a-token")
+	require.ErrorIs(t, err, shared.ErrInvalidUnsubscribeToken)
+}
+
+func TestVerifyRejectsEmptySecret(t *testing.T) {
+	token, err := Sign("", Token{Channel: shared.ChannelEmail, Address: "bob@example.com", Category: "marketing"})
+	require.NoError(t, err)
+
+	_, err = Verify("", token)
+	require.ErrorIs(t, err, shared.ErrInvalidUnsubscribeToken)
+}
@@ -0,0 +1,189 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/go-chi/chi/v5"
+)
+
+const maxUserIDLength = 64
+
+type Handler struct {
+	service UserService
+}
+
+func NewHandler(s UserService) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) Upsert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpsertUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	out, err := h.service.Upsert(r.Context(), User{
+		ID:       userID,
+		Name:     req.Name,
+		Email:    req.Email,
+		SlackID:  req.SlackID,
+		InAppID:  req.InAppID,
+		Phone:    req.Phone,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := h.service.GetByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.List(r.Context(), parseUserFilters(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *Handler) ListPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.ListPreferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, prefs)
+}
+
+// SetPreferences upserts the given preferences; preferences not in the request are kept.
+func (h *Handler) SetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req []PreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	prefs := make([]Preference, 0, len(req))
+	for _, p := range req {
+		if err := p.Validate(); err != nil {
+			http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+			return
+		}
+		prefs = append(prefs, Preference{UserID: userID, Category: p.Category, Channel: p.Channel, Mode: p.Mode})
+	}
+
+	out, err := h.service.SetPreferences(r.Context(), userID, prefs)
+	if err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) DeletePreference(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	category := chi.URLParam(r, "category")
+	channel := shared.Channel(chi.URLParam(r, "channel"))
+
+	if err := h.service.DeletePreference(r.Context(), userID, category, channel); err != nil {
+		http.Error(w, err.Error(), shared.ErrorHttpMapper(err))
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil || userID == "" || len(userID) > maxUserIDLength {
+		http.Error(w, "invalid user ID ", http.StatusBadRequest)
+		return "", false
+	}
+	return userID, true
+}
+
+func parseUserFilters(q url.Values) UserFilter {
+	var filter = UserFilter{}
+
+	var err error
+	// pagination
+	if l := q.Get("limit"); l != "" {
+		filter.Limit, err = strconv.Atoi(l)
+		if err != nil || filter.Limit < 1 {
+			filter.Limit = 0
+		}
+	}
+
+	if o := q.Get("offset"); o != "" {
+		filter.Offset, err = strconv.Atoi(o)
+		if err != nil || filter.Offset < 0 {
+			filter.Offset = 0
+		}
+	}
+
+	return filter
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+// User is a directory entry holding the channel addresses of a recipient, so
+// senders can address notifications by user ID.
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     *string   `json:"email,omitempty"`
+	SlackID   *string   `json:"slack_id,omitempty"`
+	InAppID   *string   `json:"in_app_id,omitempty"`
+	Phone     *string   `json:"phone,omitempty"` // E.164
+	Locale    string    `json:"locale"`
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Address returns the user's address on channel, or "" when none is known.
+// In-app notifications fall back to the user ID.
+func (u *User) Address(channel shared.Channel) string {
+	var v *string
+	switch channel {
+	case shared.ChannelEmail:
+		v = u.Email
+	case shared.ChannelSlack:
+		v = u.SlackID
+	case shared.ChannelInApp:
+		if u.InAppID == nil {
+			return u.ID
+		}
+		v = u.InAppID
+	case shared.ChannelSMS:
+		v = u.Phone
+	}
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+type UpsertUserRequest struct {
+	Name     string  `json:"name"`
+	Email    *string `json:"email"`
+	SlackID  *string `json:"slack_id"`
+	InAppID  *string `json:"in_app_id"`
+	Phone    *string `json:"phone"`
+	Locale   string  `json:"locale"`
+	Timezone string  `json:"timezone"`
+}
+
+func (r UpsertUserRequest) Validate() error {
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return shared.ErrInvalidTimezone
+		}
+	}
+	if r.Email == nil && r.SlackID == nil && r.InAppID == nil && r.Phone == nil {
+		return shared.ErrRequiredFieldAddress
+	}
+	if r.Phone != nil && !shared.ValidPhone(*r.Phone) {
+		return shared.ErrInvalidPhone
+	}
+	return nil
+}
+
+type UserFilter struct {
+	Limit  int
+	Offset int
+}
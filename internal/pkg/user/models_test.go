@@ -0,0 +1,27 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertUserRequestValidatesPhone(t *testing.T) {
+	phone := "+14155552671"
+	require.NoError(t, UpsertUserRequest{Phone: &phone}.Validate())
+
+	bad := "415 555 2671"
+	require.ErrorIs(t, UpsertUserRequest{Phone: &bad}.Validate(), shared.ErrInvalidPhone)
+	require.ErrorIs(t, UpsertUserRequest{}.Validate(), shared.ErrRequiredFieldAddress)
+
+	u := User{ID: "u123", Phone: &phone}
+	require.Equal(t, phone, u.Address(shared.ChannelSMS))
+	require.Equal(t, "u123", u.Address(shared.ChannelInApp))
+}
+
+func TestPreferenceRequestAcceptsSMS(t *testing.T) {
+	require.NoError(t, PreferenceRequest{Category: "marketing", Channel: shared.ChannelSMS, Mode: PreferenceDisabled}.Validate())
+	require.NoError(t, PreferenceRequest{Category: "marketing", Channel: shared.ChannelSMS, Mode: PreferenceDigest}.Validate())
+	require.ErrorIs(t, PreferenceRequest{Category: "marketing", Channel: shared.ChannelWebhook, Mode: PreferenceDisabled}.Validate(), shared.ErrInvalidPreference)
+}
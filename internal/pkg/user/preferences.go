@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type PreferenceMode string
+
+const (
+	PreferenceEnabled  PreferenceMode = "enabled"
+	PreferenceDisabled PreferenceMode = "disabled"
+	// PreferenceDigest holds notifications back and delivers them together
+	// once a day at the user's digest time.
+	PreferenceDigest PreferenceMode = "digest"
+)
+
+// AnyCategory in a preference applies to every template category on its
+// channel; a preference for the exact category wins over it.
+const AnyCategory = "*"
+
+type Preference struct {
+	UserID   string         `json:"user_id"`
+	Category string         `json:"category"`
+	Channel  shared.Channel `json:"channel"`
+	Mode     PreferenceMode `json:"mode"`
+}
+
+type PreferenceRequest struct {
+	Category string         `json:"category"`
+	Channel  shared.Channel `json:"channel"`
+	Mode     PreferenceMode `json:"mode"`
+}
+
+func (r PreferenceRequest) Validate() error {
+	if r.Category == "" {
+		return shared.ErrInvalidPreference
+	}
+	switch r.Channel {
+	case shared.ChannelEmail, shared.ChannelSlack, shared.ChannelInApp, shared.ChannelSMS:
+	default:
+		return shared.ErrInvalidPreference
+	}
+	switch r.Mode {
+	case PreferenceEnabled, PreferenceDisabled, PreferenceDigest:
+	default:
+		return shared.ErrInvalidPreference
+	}
+	return nil
+}
+
+func (s *ServiceImpl) ListPreferences(ctx context.Context, userID string) ([]*Preference, error) {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPreferences(ctx, userID)
+}
+
+func (s *ServiceImpl) SetPreferences(ctx context.Context, userID string, prefs []Preference) ([]*Preference, error) {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertPreferences(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPreferences(ctx, userID)
+}
+
+func (s *ServiceImpl) DeletePreference(ctx context.Context, userID, category string, channel shared.Channel) error {
+	deleted, err := s.repo.DeletePreference(ctx, userID, category, channel)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return shared.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ResolvePreference returns how the user wants notifications of category on
+// channel delivered. Without a matching preference delivery is enabled.
+func (s *ServiceImpl) ResolvePreference(ctx context.Context, userID, category string, channel shared.Channel) (PreferenceMode, error) {
+	for _, c := range []string{category, AnyCategory} {
+		mode, err := s.repo.GetPreferenceMode(ctx, userID, c, channel)
+		if err == nil {
+			return mode, nil
+		}
+		if !errors.Is(err, shared.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+	return PreferenceEnabled, nil
+}
@@ -0,0 +1,18 @@
+package user
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type UserRepository interface {
+	Upsert(ctx context.Context, u User) error
+	GetByID(ctx context.Context, userID string) (*User, error)
+	List(ctx context.Context, filter UserFilter) ([]*User, error)
+	Delete(ctx context.Context, userID string) (bool, error)
+	ListPreferences(ctx context.Context, userID string) ([]*Preference, error)
+	UpsertPreferences(ctx context.Context, userID string, prefs []Preference) error
+	DeletePreference(ctx context.Context, userID, category string, channel shared.Channel) (bool, error)
+	GetPreferenceMode(ctx context.Context, userID, category string, channel shared.Channel) (PreferenceMode, error)
+}
@@ -0,0 +1,25 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.List)
+	r.Put("/{id}", h.Upsert)
+	r.Get("/{id}", h.GetByID)
+	r.Delete("/{id}", h.Delete)
+	r.Get("/{id}/preferences", h.ListPreferences)
+	r.Put("/{id}/preferences", h.SetPreferences)
+	r.Delete("/{id}/preferences/{category}/{channel}", h.DeletePreference)
+
+	return r
+}
+
+func NewUserRoutes(service UserService) http.Handler {
+	return NewHandler(service).Routes()
+}
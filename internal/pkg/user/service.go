@@ -0,0 +1,18 @@
+package user
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type UserService interface {
+	Upsert(ctx context.Context, u User) (*User, error)
+	GetByID(ctx context.Context, userID string) (*User, error)
+	List(ctx context.Context, filter UserFilter) ([]*User, error)
+	Delete(ctx context.Context, userID string) error
+	ListPreferences(ctx context.Context, userID string) ([]*Preference, error)
+	SetPreferences(ctx context.Context, userID string, prefs []Preference) ([]*Preference, error)
+	DeletePreference(ctx context.Context, userID, category string, channel shared.Channel) error
+	ResolvePreference(ctx context.Context, userID, category string, channel shared.Channel) (PreferenceMode, error)
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"context"
+
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+type ServiceImpl struct {
+	repo UserRepository
+}
+
+func NewUserService(repo UserRepository) UserService {
+	return &ServiceImpl{
+		repo: repo,
+	}
+}
+
+func (s *ServiceImpl) Upsert(ctx context.Context, u User) (*User, error) {
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+	if err := s.repo.Upsert(ctx, u); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, u.ID)
+}
+
+func (s *ServiceImpl) GetByID(ctx context.Context, userID string) (*User, error) {
+	return s.repo.GetByID(ctx, userID)
+}
+
+func (s *ServiceImpl) List(ctx context.Context, filter UserFilter) ([]*User, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func (s *ServiceImpl) Delete(ctx context.Context, userID string) error {
+	deleted, err := s.repo.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return shared.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+)
+
+func (r *userStore) ListPreferences(ctx context.Context, userID string) ([]*user.Preference, error) {
+	rows, err := r.db.QueryContext(ctx, "ListUserPreferences", ListUserPreferencesQuery, userID)
+	if err != nil {
+		r.log.Error(ctx, "failed to list user preferences", logger.String("userID", userID), logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out = []*user.Preference{}
+	for rows.Next() {
+		var p user.Preference
+		if err := rows.Scan(&p.UserID, &p.Category, &p.Channel, &p.Mode); err != nil {
+			r.log.Error(ctx, "failed to scan user preferences", logger.Error(err))
+			return nil, err
+		}
+		out = append(out, &p)
+	}
+
+	return out, rows.Err()
+}
+
+func (r *userStore) UpsertPreferences(ctx context.Context, userID string, prefs []user.Preference) error {
+	err := r.db.WithTx(ctx, func(tx *mysqlwrapper.Tx) error {
+		for _, p := range prefs {
+			if _, err := tx.ExecContext(ctx, "UpsertUserPreference", UpsertUserPreferenceQuery, userID, p.Category, p.Channel, p.Mode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error(ctx, "failed to upsert user preferences", logger.String("userID", userID), logger.Error(err))
+	}
+	return err
+}
+
+func (r *userStore) DeletePreference(ctx context.Context, userID, category string, channel shared.Channel) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteUserPreference", DeleteUserPreferenceQuery, userID, category, channel)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete user preference", logger.String("userID", userID), logger.Error(err))
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *userStore) GetPreferenceMode(ctx context.Context, userID, category string, channel shared.Channel) (user.PreferenceMode, error) {
+	var mode user.PreferenceMode
+	err := r.db.QueryRowContext(ctx, "GetUserPreferenceMode", GetUserPreferenceModeQuery, userID, category, channel).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return "", shared.ErrRecordNotFound
+	}
+	if err != nil {
+		r.log.Error(ctx, "failed to get user preference", logger.String("userID", userID), logger.Error(err))
+		return "", err
+	}
+	return mode, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import "github.com/ckshitij/notify-srv/internal/pkg/user"
+
+const (
+	UpsertUserQuery = `
+		INSERT INTO users
+			(id, name, email, slack_id, in_app_id, phone, locale, timezone)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			name = VALUES(name),
+			email = VALUES(email),
+			slack_id = VALUES(slack_id),
+			in_app_id = VALUES(in_app_id),
+			phone = VALUES(phone),
+			locale = VALUES(locale),
+			timezone = VALUES(timezone)
+	`
+
+	userColumns = `
+		id, name, email, slack_id, in_app_id, phone,
+		locale, timezone, created_at, updated_at
+	`
+
+	GetUserByIDQuery = `SELECT ` + userColumns + `
+		FROM users
+		WHERE id = ?
+	`
+
+	DeleteUserQuery = `
+		DELETE FROM users
+		WHERE id = ?
+	`
+
+	ListUserPreferencesQuery = `
+		SELECT user_id, category, channel, mode
+		FROM user_preferences
+		WHERE user_id = ?
+		ORDER BY category, channel
+	`
+
+	UpsertUserPreferenceQuery = `
+		INSERT INTO user_preferences
+			(user_id, category, channel, mode)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			mode = VALUES(mode)
+	`
+
+	DeleteUserPreferenceQuery = `
+		DELETE FROM user_preferences
+		WHERE user_id = ? AND category = ? AND channel = ?
+	`
+
+	GetUserPreferenceModeQuery = `
+		SELECT mode
+		FROM user_preferences
+		WHERE user_id = ? AND category = ? AND channel = ?
+	`
+)
+
+func buildListUsersQuery(filter user.UserFilter) (string, []any) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY id`
+	args := []any{}
+
+	// pagination
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		if filter.Limit <= 0 {
+			// MySQL only accepts OFFSET together with LIMIT
+			query += " LIMIT 18446744073709551615"
+		}
+		query += " OFFSET ?"
+		args = append(args, filter.Offset)
+	}
+
+	return query, args
+}
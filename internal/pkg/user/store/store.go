@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ckshitij/notify-srv/internal/logger"
+	mysqlwrapper "github.com/ckshitij/notify-srv/internal/mysql"
+	"github.com/ckshitij/notify-srv/internal/pkg/user"
+	"github.com/ckshitij/notify-srv/internal/shared"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userCacheByID = "user:id:%s"
+	cacheExpiry   = 5 * time.Minute
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type userStore struct {
+	db  *mysqlwrapper.DB
+	rdb *redis.Client
+	log logger.Logger
+}
+
+func NewUserRepository(db *mysqlwrapper.DB, rdb *redis.Client, log logger.Logger) user.UserRepository {
+	return &userStore{db, rdb, log}
+}
+
+func (r *userStore) Upsert(ctx context.Context, u user.User) error {
+	_, err := r.db.ExecContext(ctx, "UpsertUser", UpsertUserQuery,
+		u.ID,
+		u.Name,
+		u.Email,
+		u.SlackID,
+		u.InAppID,
+		u.Phone,
+		u.Locale,
+		u.Timezone,
+	)
+	if err != nil {
+		r.log.Error(ctx, "failed to upsert user", logger.String("userID", u.ID), logger.Error(err))
+		return err
+	}
+
+	r.invalidate(ctx, u.ID)
+	return nil
+}
+
+func (r *userStore) GetByID(ctx context.Context, userID string) (*user.User, error) {
+	// Check cache first
+	key := fmt.Sprintf(userCacheByID, userID)
+	cached, err := r.rdb.Get(ctx, key).Result()
+	if err == nil {
+		var u user.User
+		if err := json.Unmarshal([]byte(cached), &u); err == nil {
+			return &u, nil
+		}
+	}
+
+	u, err := scanUser(r.db.QueryRowContext(ctx, "GetUserByID", GetUserByIDQuery, userID))
+	if err == sql.ErrNoRows {
+		r.log.Info(ctx, "user not found", logger.String("userID", userID))
+		return nil, shared.ErrRecordNotFound
+	}
+	if err != nil {
+		r.log.Error(ctx, "failed to get user", logger.String("userID", userID), logger.Error(err))
+		return nil, err
+	}
+
+	// Cache the result
+	serialized, _ := json.Marshal(u)
+	r.rdb.Set(ctx, key, serialized, cacheExpiry)
+
+	return u, nil
+}
+
+func (r *userStore) List(ctx context.Context, filter user.UserFilter) ([]*user.User, error) {
+	query, args := buildListUsersQuery(filter)
+	rows, err := r.db.QueryContext(ctx, "ListUsers", query, args...)
+	if err != nil {
+		r.log.Error(ctx, "failed to list users", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out = []*user.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			r.log.Error(ctx, "failed to scan list users", logger.Error(err))
+			return nil, err
+		}
+		out = append(out, u)
+	}
+
+	return out, rows.Err()
+}
+
+func (r *userStore) Delete(ctx context.Context, userID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DeleteUser", DeleteUserQuery, userID)
+	if err != nil {
+		r.log.Error(ctx, "failed to delete user", logger.String("userID", userID), logger.Error(err))
+		return false, err
+	}
+
+	r.invalidate(ctx, userID)
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// invalidate drops the cached user; a failure only delays the change until the cache entry expires.
+func (r *userStore) invalidate(ctx context.Context, userID string) {
+	key := fmt.Sprintf(userCacheByID, userID)
+	if err := r.rdb.Del(ctx, key).Err(); err != nil {
+		r.log.Warn(ctx, "failed to invalidate user cache", logger.String("key", key), logger.Error(err))
+	}
+}
+
+func scanUser(row scanner) (*user.User, error) {
+	var u user.User
+	if err := row.Scan(
+		&u.ID,
+		&u.Name,
+		&u.Email,
+		&u.SlackID,
+		&u.InAppID,
+		&u.Phone,
+		&u.Locale,
+		&u.Timezone,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -16,19 +16,68 @@ var (
 	ErrInvalidRecipient           = errors.New("invalid recipient, please check the format")
 	ErrInvalidTemplateKeyValue    = errors.New("invalid template_key_value, please check the format")
 	ErrRecordNotFound             = errors.New("record not found")
+	ErrDeadLetterNotConfigured    = errors.New("dead-letter topic not configured for channel")
+	ErrInvalidOffsetRange         = errors.New("invalid offset range")
+	ErrRequiredFieldScheduledAt   = errors.New("scheduled_at is required")
+	ErrNotificationNotScheduled   = errors.New("notification is no longer scheduled")
+	ErrInvalidCronExpression      = errors.New("invalid cron expression")
+	ErrInvalidTimezone            = errors.New("invalid timezone, expected an IANA name such as Europe/Berlin")
+	ErrNoUpcomingOccurrence       = errors.New("schedule has no occurrence before end_at")
+	ErrRecurringStateConflict     = errors.New("recurring schedule is not in a state that allows this operation")
+	ErrInvalidIdempotencyKey      = errors.New("idempotency key must be at most 255 characters")
+	ErrIdempotencyKeyReused       = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyKeyInFlight     = errors.New("a request with this idempotency key is still in progress")
+	ErrInvalidClientID            = errors.New("client id must be at most 64 characters")
+	ErrInvalidLocalTime           = errors.New("invalid local_time, expected 2006-01-02T15:04:05 without an offset")
+	ErrConflictingScheduleTime    = errors.New("provide either scheduled_at or local_time with timezone, not both")
+	ErrInvalidQuietHours          = errors.New("invalid quiet hours, expected timezone and start/end as HH:MM")
+	ErrInvalidPriority            = errors.New("invalid priority, expected normal or urgent")
+	ErrEmptyBatch                 = errors.New("batch has no valid recipients")
+	ErrBatchTooLarge              = errors.New("batch exceeds the maximum number of recipients")
+	ErrRequiredFieldTemplateName  = errors.New("template_name is required")
+	ErrRequiredFieldRecipients    = errors.New("at least one channel recipient is required")
+	ErrRequiredFieldAddress       = errors.New("at least one of email, slack_id, in_app_id or phone is required")
+	ErrInvalidPhone               = errors.New("phone must be in E.164 format, e.g. +14155552671")
+	ErrTemplateChannelMismatch    = errors.New("template belongs to a different channel")
+	ErrRecurringOptionUnsupported = errors.New("priority, fallback and attachments are not supported for recurring schedules")
+	ErrInvalidPreference          = errors.New("invalid preference, expected category, channel and mode of enabled, disabled or digest")
+	ErrInvalidSuppression         = errors.New("invalid suppression, expected channel, address and reason of hard_bounce, complaint, unsubscribed or manual")
+	ErrInvalidCSV                 = errors.New("invalid CSV, expected a header row with an address column")
+	ErrInvalidUnsubscribeToken    = errors.New("invalid unsubscribe token")
+	ErrHTMLBodyEmailOnly          = errors.New("html_body is only supported for email templates")
+	ErrAttachmentsEmailOnly       = errors.New("attachments are only supported for email")
+	ErrTooManyAttachments         = errors.New("too many attachments")
+	ErrAttachmentTooLarge         = errors.New("attachments exceed the maximum size")
+	ErrAttachmentURLNotAllowed    = errors.New("attachment url is not under an allowed prefix")
+	ErrSenderNotAllowed           = errors.New("from address is not an allowed sender identity")
+	ErrBlocksSlackOnly            = errors.New("blocks are only supported for slack templates")
+	ErrInvalidReference           = errors.New("thread_of and update_of must reference a slack notification")
+	ErrWebhookURLNotAllowed       = errors.New("webhook url is not under an allowed prefix")
+	ErrSubjectNotAllowedForSMS    = errors.New("sms templates cannot have a subject")
 )
 
 func ErrorHttpMapper(err error) int {
 	switch err {
 	case ErrRequiredFieldBody, ErrRequiredFieldSubject,
 		ErrInvalidRecipient, ErrInvalidTemplateKeyValue,
-		ErrRequiredFieldChannel, ErrRequiredFieldName, ErrTemplateNotFound:
+		ErrRequiredFieldChannel, ErrRequiredFieldName, ErrTemplateNotFound,
+		ErrDeadLetterNotConfigured, ErrInvalidOffsetRange, ErrInvalidIdempotencyKey, ErrInvalidClientID,
+		ErrRequiredFieldScheduledAt, ErrInvalidCronExpression, ErrInvalidTimezone,
+		ErrNoUpcomingOccurrence, ErrInvalidLocalTime, ErrConflictingScheduleTime,
+		ErrInvalidQuietHours, ErrInvalidPriority, ErrEmptyBatch, ErrBatchTooLarge,
+		ErrRequiredFieldTemplateName, ErrRequiredFieldRecipients, ErrRequiredFieldAddress,
+		ErrInvalidPreference, ErrInvalidSuppression, ErrInvalidCSV, ErrInvalidUnsubscribeToken,
+		ErrHTMLBodyEmailOnly, ErrAttachmentsEmailOnly, ErrTooManyAttachments, ErrAttachmentTooLarge,
+		ErrAttachmentURLNotAllowed, ErrSenderNotAllowed, ErrBlocksSlackOnly,
+		ErrInvalidReference, ErrWebhookURLNotAllowed, ErrSubjectNotAllowedForSMS,
+		ErrInvalidPhone, ErrTemplateChannelMismatch, ErrRecurringOptionUnsupported:
 		return http.StatusBadRequest
 	case ErrSystemTemplateNotPermitted:
 		return http.StatusForbidden
 	case ErrRecordNotFound:
 		return http.StatusNotFound
-	case ErrDuplicateTemplateRecord:
+	case ErrDuplicateTemplateRecord, ErrIdempotencyKeyReused, ErrIdempotencyKeyInFlight,
+		ErrNotificationNotScheduled, ErrRecurringStateConflict:
 		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
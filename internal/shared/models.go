@@ -1,11 +1,15 @@
 package shared
 
+import "regexp"
+
 type Channel string
 
 const (
-	ChannelEmail Channel = "email"
-	ChannelSlack Channel = "slack"
-	ChannelInApp Channel = "in_app"
+	ChannelEmail   Channel = "email"
+	ChannelSlack   Channel = "slack"
+	ChannelInApp   Channel = "in_app"
+	ChannelWebhook Channel = "webhook"
+	ChannelSMS     Channel = "sms"
 )
 
 type TemplateType string
@@ -14,3 +18,12 @@ const (
 	SystemTemplate TemplateType = "system"
 	UserTemplate   TemplateType = "user"
 )
+
+// e164 matches a phone number in E.164 format: a plus sign and up to 15
+// digits, starting with the country code.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// ValidPhone reports whether v is a phone number in E.164 format.
+func ValidPhone(v string) bool {
+	return e164.MatchString(v)
+}
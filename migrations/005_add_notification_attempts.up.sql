@@ -0,0 +1,2 @@
+ALTER TABLE notifications
+  ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER status;
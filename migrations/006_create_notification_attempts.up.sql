@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS notification_attempts (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  notification_id BIGINT NOT NULL,
+
+  attempt INT NOT NULL,
+  sender VARCHAR(20) NOT NULL,
+
+  error TEXT NULL,
+  provider_response TEXT NULL,
+
+  started_at DATETIME(3) NOT NULL,
+  finished_at DATETIME(3) NOT NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_notification_attempt (notification_id, attempt)
+) ENGINE=InnoDB;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  client_id VARCHAR(64) NOT NULL,
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+
+  -- NULL while the original request is still in flight
+  response JSON NULL,
+
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY uniq_client_key (client_id, idempotency_key),
+  INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB;
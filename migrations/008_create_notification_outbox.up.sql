@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS notification_outbox (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  notification_id BIGINT NOT NULL,
+
+  topic VARCHAR(255) NOT NULL,
+  msg_key VARCHAR(255) NOT NULL,
+  payload BLOB NOT NULL,
+
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  published_at DATETIME NULL,
+
+  INDEX idx_status_id (status, id),
+  INDEX idx_notification_id (notification_id)
+) ENGINE=InnoDB;
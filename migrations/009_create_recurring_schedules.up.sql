@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS recurring_schedules (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  cron_expr VARCHAR(100) NOT NULL,
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+
+  channel VARCHAR(20) NOT NULL,
+  template_id BIGINT NOT NULL,
+
+  recipient JSON NOT NULL,
+  template_kv JSON NOT NULL,
+
+  -- active | paused | completed
+  status VARCHAR(20) NOT NULL,
+
+  start_at DATETIME NULL,
+  end_at DATETIME NULL,
+  next_run_at DATETIME NULL,
+  last_run_at DATETIME NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_status_next_run (status, next_run_at),
+  INDEX idx_template_id (template_id),
+
+  CONSTRAINT fk_recurring_schedules_template
+    FOREIGN KEY (template_id)
+    REFERENCES templates(id)
+    ON UPDATE CASCADE
+    ON DELETE RESTRICT
+) ENGINE=InnoDB;
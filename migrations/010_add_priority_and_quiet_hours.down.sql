@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS quiet_hours;
+
+ALTER TABLE notifications
+  DROP COLUMN priority;
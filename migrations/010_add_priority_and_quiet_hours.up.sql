@@ -0,0 +1,20 @@
+ALTER TABLE notifications
+  ADD COLUMN priority VARCHAR(10) NOT NULL DEFAULT 'normal' AFTER status;
+
+CREATE TABLE IF NOT EXISTS quiet_hours (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  channel VARCHAR(20) NOT NULL,
+  -- email address, slack user or in-app user the window applies to
+  recipient VARCHAR(255) NOT NULL,
+
+  timezone VARCHAR(64) NOT NULL,
+  start_time TIME NOT NULL,
+  end_time TIME NOT NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY uniq_channel_recipient (channel, recipient)
+) ENGINE=InnoDB;
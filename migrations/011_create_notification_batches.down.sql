@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+  DROP INDEX idx_batch_id,
+  DROP COLUMN batch_id;
+
+DROP TABLE IF EXISTS notification_batches;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS notification_batches (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  channel VARCHAR(20) NOT NULL,
+  template_id BIGINT NOT NULL,
+
+  -- recipients in the request and how many of them were valid
+  total INT NOT NULL,
+  accepted INT NOT NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+
+ALTER TABLE notifications
+  ADD COLUMN batch_id BIGINT NULL AFTER id,
+  ADD INDEX idx_batch_id (batch_id);
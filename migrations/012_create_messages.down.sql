@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+  DROP INDEX idx_message_id,
+  DROP COLUMN message_id;
+
+DROP TABLE IF EXISTS messages;
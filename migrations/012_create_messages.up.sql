@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS messages (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  -- templates.name, resolved per channel for every child notification
+  template_name VARCHAR(100) NOT NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+
+ALTER TABLE notifications
+  ADD COLUMN message_id BIGINT NULL AFTER batch_id,
+  ADD INDEX idx_message_id (message_id);
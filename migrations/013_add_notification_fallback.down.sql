@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+  DROP INDEX idx_parent_id,
+  DROP COLUMN fallback,
+  DROP COLUMN parent_id;
@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+  ADD COLUMN parent_id BIGINT NULL AFTER message_id,
+  -- remaining fallback steps, handed to the next notification of the chain
+  ADD COLUMN fallback JSON NULL AFTER template_kv,
+  ADD INDEX idx_parent_id (parent_id);
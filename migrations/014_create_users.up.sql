@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+  -- caller assigned identifier, e.g. the id in the caller's own user system
+  id VARCHAR(64) PRIMARY KEY,
+
+  name VARCHAR(255) NOT NULL DEFAULT '',
+
+  email VARCHAR(255) NULL,
+  slack_id VARCHAR(64) NULL,
+  in_app_id VARCHAR(64) NULL,
+
+  locale VARCHAR(16) NOT NULL DEFAULT '',
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX idx_email (email)
+) ENGINE=InnoDB;
@@ -0,0 +1,7 @@
+ALTER TABLE notifications
+  DROP COLUMN status_reason;
+
+DROP TABLE IF EXISTS user_preferences;
+
+ALTER TABLE templates
+  DROP COLUMN category;
@@ -0,0 +1,25 @@
+ALTER TABLE templates
+  ADD COLUMN category VARCHAR(64) NOT NULL DEFAULT 'general' AFTER type;
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+  user_id VARCHAR(64) NOT NULL,
+  -- template category, or * for every category on the channel
+  category VARCHAR(64) NOT NULL,
+  channel VARCHAR(20) NOT NULL,
+
+  -- enabled | disabled | digest
+  mode VARCHAR(10) NOT NULL,
+
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (user_id, category, channel),
+
+  CONSTRAINT fk_user_preferences_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+ALTER TABLE notifications
+  ADD COLUMN status_reason VARCHAR(255) NULL AFTER status;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS suppressions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+  channel VARCHAR(20) NOT NULL,
+  -- normalized: trimmed, and lower-cased for email
+  address VARCHAR(255) NOT NULL,
+
+  -- hard_bounce | complaint | unsubscribed | manual
+  reason VARCHAR(20) NOT NULL,
+  -- where the entry came from, e.g. admin or csv_import
+  source VARCHAR(64) NOT NULL,
+
+  -- NULL never expires
+  expires_at DATETIME NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY uq_channel_address (channel, address),
+  INDEX idx_reason (reason)
+) ENGINE=InnoDB;
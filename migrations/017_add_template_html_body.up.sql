@@ -0,0 +1,3 @@
+-- email only; NULL sends plain text
+ALTER TABLE templates
+  ADD COLUMN html_body MEDIUMTEXT NULL AFTER body;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS notification_attachments (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  notification_id BIGINT NOT NULL,
+
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+
+  -- set for attachments fetched at send time, content is then NULL
+  url VARCHAR(2048) NULL,
+
+  -- inline parts are referenced from the HTML body as cid:<content_id>
+  inline BOOLEAN NOT NULL DEFAULT FALSE,
+  content_id VARCHAR(255) NULL,
+
+  size BIGINT NOT NULL DEFAULT 0,
+  content LONGBLOB NULL,
+
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX idx_notification_id (notification_id),
+
+  CONSTRAINT fk_attachments_notification
+    FOREIGN KEY (notification_id)
+    REFERENCES notifications(id)
+    ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,2 @@
+ALTER TABLE templates
+  DROP COLUMN blocks;
@@ -0,0 +1,3 @@
+-- slack only; a Block Kit layout template, NULL sends text
+ALTER TABLE templates
+  ADD COLUMN blocks MEDIUMTEXT NULL AFTER html_body;
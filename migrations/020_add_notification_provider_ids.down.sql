@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+  DROP COLUMN provider_message_id,
+  DROP COLUMN provider_channel;
@@ -0,0 +1,4 @@
+-- the delivered message, e.g. slack channel and ts, for thread replies and updates
+ALTER TABLE notifications
+  ADD COLUMN provider_channel VARCHAR(64) NULL AFTER status_reason,
+  ADD COLUMN provider_message_id VARCHAR(255) NULL AFTER provider_channel;
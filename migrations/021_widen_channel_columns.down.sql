@@ -0,0 +1,8 @@
+UPDATE templates SET channel = 'in-app' WHERE channel = 'in_app';
+UPDATE notifications SET channel = 'in-app' WHERE channel = 'in_app';
+
+ALTER TABLE notifications
+  MODIFY COLUMN channel ENUM('email', 'slack', 'in-app') NOT NULL;
+
+ALTER TABLE templates
+  MODIFY COLUMN channel ENUM('email', 'slack', 'in-app') NOT NULL;
@@ -0,0 +1,10 @@
+-- channels are validated by the service, as in the newer tables; this also
+-- admits webhook and in_app, which the original ENUM spelled in-app
+ALTER TABLE templates
+  MODIFY COLUMN channel VARCHAR(20) NOT NULL;
+
+ALTER TABLE notifications
+  MODIFY COLUMN channel VARCHAR(20) NOT NULL;
+
+UPDATE templates SET channel = 'in_app' WHERE channel = 'in-app';
+UPDATE notifications SET channel = 'in_app' WHERE channel = 'in-app';
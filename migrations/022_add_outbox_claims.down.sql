@@ -0,0 +1,3 @@
+ALTER TABLE notification_outbox
+  DROP INDEX idx_status_claimed,
+  DROP COLUMN claimed_until;
@@ -0,0 +1,5 @@
+-- Relay instances lease the rows they publish; a failed row waits until
+-- claimed_until before it is tried again, and turns dead after max attempts.
+ALTER TABLE notification_outbox
+  ADD COLUMN claimed_until DATETIME NULL AFTER attempts,
+  ADD INDEX idx_status_claimed (status, claimed_until, id);
@@ -0,0 +1,2 @@
+ALTER TABLE users
+  ADD COLUMN phone VARCHAR(16) NULL AFTER in_app_id;
@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+  DROP KEY idx_digest_key_status,
+  DROP COLUMN digest_key;
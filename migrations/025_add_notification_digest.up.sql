@@ -0,0 +1,4 @@
+-- notifications held for the same user, channel and digest time, delivered together
+ALTER TABLE notifications
+  ADD COLUMN digest_key VARCHAR(255) NULL AFTER status_reason,
+  ADD KEY idx_digest_key_status (digest_key, status);